@@ -1,21 +1,18 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import twodee "../libs/twodee"
 
 type AudioSystem struct {
-	app                             *Application
-	backgroundMusic                 *twodee.Music
-	planetDropEffect                *twodee.SoundEffect
-	planetFireDeathEffect           *twodee.SoundEffect
-	planetCollisionEffect           *twodee.SoundEffect
-	victoryEffect                   *twodee.SoundEffect
-	backgroundMusicObserverId       int
-	pauseMusicObserverId            int
-	resumeMusicObserverId           int
-	planetDropEffectObserverId      int
-	planetFireDeathEffectObserverId int
-	planetCollisionEffectObserverId int
-	gameOverObserverId              int
+	app                   *Application
+	backgroundMusic       *twodee.Music
+	planetDropEffect      *twodee.SoundEffect
+	planetFireDeathEffect *twodee.SoundEffect
+	planetCollisionEffect *twodee.SoundEffect
+	planetEscapedEffect   *twodee.SoundEffect
+	victoryEffect         *twodee.SoundEffect
 }
 
 func (a *AudioSystem) PlayBackgroundMusic(e twodee.GETyper) {
@@ -52,6 +49,12 @@ func (a *AudioSystem) PlayPlanetCollisionEffect(e twodee.GETyper) {
 	}
 }
 
+func (a *AudioSystem) PlayPlanetEscapedEffect(e twodee.GETyper) {
+	if a.planetEscapedEffect.IsPlaying(6) == 0 {
+		a.planetEscapedEffect.PlayChannel(6, 1)
+	}
+}
+
 func (a *AudioSystem) OnGameOver(e twodee.GETyper) {
 	if twodee.MusicIsPlaying() {
 		twodee.PauseMusic()
@@ -60,17 +63,12 @@ func (a *AudioSystem) OnGameOver(e twodee.GETyper) {
 }
 
 func (a *AudioSystem) Delete() {
-	a.app.GameEventHandler.RemoveObserver(PlayBackgroundMusic, a.backgroundMusicObserverId)
-	a.app.GameEventHandler.RemoveObserver(PauseMusic, a.pauseMusicObserverId)
-	a.app.GameEventHandler.RemoveObserver(ResumeMusic, a.resumeMusicObserverId)
-	a.app.GameEventHandler.RemoveObserver(ReleasePlanet, a.planetDropEffectObserverId)
-	a.app.GameEventHandler.RemoveObserver(PlanetFireDeath, a.planetFireDeathEffectObserverId)
-	a.app.GameEventHandler.RemoveObserver(PlanetCollision, a.planetCollisionEffectObserverId)
-	a.app.GameEventHandler.RemoveObserver(GameOver, a.gameOverObserverId)
+	a.app.Events.Release(a)
 	a.backgroundMusic.Delete()
 	a.planetDropEffect.Delete()
 	a.planetFireDeathEffect.Delete()
 	a.planetCollisionEffect.Delete()
+	a.planetEscapedEffect.Delete()
 	a.victoryEffect.Delete()
 }
 
@@ -80,6 +78,7 @@ func NewAudioSystem(app *Application) (audioSystem *AudioSystem, err error) {
 		planetDropEffect      *twodee.SoundEffect
 		planetFireDeathEffect *twodee.SoundEffect
 		planetCollisionEffect *twodee.SoundEffect
+		planetEscapedEffect   *twodee.SoundEffect
 		victoryEffect         *twodee.SoundEffect
 	)
 	if backgroundMusic, err = twodee.NewMusic("assets/music/Birth_of_a_Phantom_Planet.ogg"); err != nil {
@@ -94,6 +93,10 @@ func NewAudioSystem(app *Application) (audioSystem *AudioSystem, err error) {
 	if planetCollisionEffect, err = twodee.NewSoundEffect("assets/sound_effects/PlanetCollision.ogg"); err != nil {
 		return
 	}
+	// There is no sample for escapes yet, so a quiet drop stands in.
+	if planetEscapedEffect, err = twodee.NewSoundEffect("assets/sound_effects/PlanetDrop.ogg"); err != nil {
+		return
+	}
 	if victoryEffect, err = twodee.NewSoundEffect("assets/sound_effects/VictoryEffect.ogg"); err != nil {
 		return
 	}
@@ -103,17 +106,20 @@ func NewAudioSystem(app *Application) (audioSystem *AudioSystem, err error) {
 		planetDropEffect:      planetDropEffect,
 		planetFireDeathEffect: planetFireDeathEffect,
 		planetCollisionEffect: planetCollisionEffect,
+		planetEscapedEffect:   planetEscapedEffect,
 		victoryEffect:         victoryEffect,
 	}
 	planetDropEffect.SetVolume(100)
 	planetFireDeathEffect.SetVolume(60)
 	planetCollisionEffect.SetVolume(60)
-	audioSystem.backgroundMusicObserverId = app.GameEventHandler.AddObserver(PlayBackgroundMusic, audioSystem.PlayBackgroundMusic)
-	audioSystem.planetDropEffectObserverId = app.GameEventHandler.AddObserver(ReleasePlanet, audioSystem.PlayPlanetDropEffect)
-	audioSystem.planetFireDeathEffectObserverId = app.GameEventHandler.AddObserver(PlanetFireDeath, audioSystem.PlayPlanetFireDeathEffect)
-	audioSystem.planetCollisionEffectObserverId = app.GameEventHandler.AddObserver(PlanetCollision, audioSystem.PlayPlanetCollisionEffect)
-	audioSystem.pauseMusicObserverId = app.GameEventHandler.AddObserver(PauseMusic, audioSystem.PauseMusic)
-	audioSystem.resumeMusicObserverId = app.GameEventHandler.AddObserver(ResumeMusic, audioSystem.ResumeMusic)
-	audioSystem.gameOverObserverId = app.GameEventHandler.AddObserver(GameOver, audioSystem.OnGameOver)
+	planetEscapedEffect.SetVolume(30)
+	app.Events.Subscribe(audioSystem, PlayBackgroundMusic, audioSystem.PlayBackgroundMusic)
+	app.Events.Subscribe(audioSystem, ReleasePlanet, audioSystem.PlayPlanetDropEffect)
+	app.Events.Subscribe(audioSystem, PlanetFireDeath, audioSystem.PlayPlanetFireDeathEffect)
+	app.Events.Subscribe(audioSystem, PlanetCollision, audioSystem.PlayPlanetCollisionEffect)
+	app.Events.Subscribe(audioSystem, PlanetEscaped, audioSystem.PlayPlanetEscapedEffect)
+	app.Events.Subscribe(audioSystem, PauseMusic, audioSystem.PauseMusic)
+	app.Events.Subscribe(audioSystem, ResumeMusic, audioSystem.ResumeMusic)
+	app.Events.Subscribe(audioSystem, GameOver, audioSystem.OnGameOver)
 	return
 }
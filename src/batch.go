@@ -0,0 +1,202 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+// ScenarioLaunch is a drag from (X, Y) to (ToX, ToY), At seconds into the
+// game.
+type ScenarioLaunch struct {
+	At  float64 `json:"at"`
+	X   float32 `json:"x"`
+	Y   float32 `json:"y"`
+	ToX float32 `json:"toX"`
+	ToY float32 `json:"toY"`
+}
+
+// Scenario is a fixed list of launches, read from a JSON file.
+type Scenario struct {
+	Launches []ScenarioLaunch `json:"launches"`
+}
+
+func LoadScenario(path string) (scenario *Scenario, err error) {
+	var data []byte
+	if data, err = ioutil.ReadFile(path); err != nil {
+		return
+	}
+	scenario = &Scenario{}
+	if err = json.Unmarshal(data, scenario); err != nil {
+		err = fmt.Errorf("Could not parse scenario %v: %v", path, err)
+	}
+	return
+}
+
+// ScenarioPlayer plays the launches of a scenario in order.
+type ScenarioPlayer struct {
+	scenario *Scenario
+	clock    time.Duration
+	next     int
+}
+
+func NewScenarioPlayer(scenario *Scenario) *ScenarioPlayer {
+	return &ScenarioPlayer{scenario: scenario}
+}
+
+func (s *ScenarioPlayer) Play(elapsed time.Duration, view SimView) (actions []PlayerAction) {
+	s.clock += elapsed
+	for ; s.next < len(s.scenario.Launches); s.next++ {
+		var launch = s.scenario.Launches[s.next]
+		if time.Duration(launch.At*float64(time.Second)) > s.clock {
+			break
+		}
+		actions = append(actions, Drop(twodee.Pt(launch.X, launch.Y)), Release(twodee.Pt(launch.ToX, launch.ToY)))
+	}
+	return
+}
+
+// BatchResult is the outcome of one headless game.
+type BatchResult struct {
+	Seed            int64
+	PeakPopulation  int
+	Launched        int
+	LostToFire      int
+	LostToCollision int
+	LostToVoid      int
+	Survivors       int
+	// Mean age of every planet launched, at its death or the end of the
+	// game.
+	MeanSurvival  time.Duration
+	CheevosEarned int
+	CheevosFailed int
+}
+
+// Plays a whole game without a window, with planets launched by player.
+func RunHeadless(seed int64, field twodee.Rectangle, length time.Duration, player Player) (result BatchResult) {
+	var (
+		events   = NewEventBus(false)
+		sim      = NewSimulation(field, events, seed)
+		launcher = NewLauncher(sim)
+		cheevos  = NewCheevos(events, sim)
+		step     = twodee.Step60Hz
+		survival time.Duration
+	)
+	result.Seed = seed
+	launcher.ListenForBot(events)
+	events.Clock = func() int {
+		return sim.Tick
+	}
+	// Death events repeat while a planet's death plays out, so count each
+	// planet once.
+	var dead = map[int]bool{}
+	var lost = func(count *int) func(*PlanetEvent) {
+		return func(event *PlanetEvent) {
+			if dead[event.PlanetId] {
+				return
+			}
+			dead[event.PlanetId] = true
+			*count++
+			survival += event.Planet.Age
+		}
+	}
+	events.OnPlanet(&result, PlanetFireDeath, lost(&result.LostToFire))
+	events.OnPlanet(&result, PlanetCollision, lost(&result.LostToCollision))
+	events.OnPlanet(&result, PlanetEscaped, lost(&result.LostToVoid))
+	events.OnCheevo(&result, CheevoSuccess, func(*CheevoEvent) {
+		result.CheevosEarned++
+	})
+	events.OnCheevo(&result, CheevoFailure, func(*CheevoEvent) {
+		result.CheevosFailed++
+	})
+	for clock := time.Duration(0); clock < length; clock += step {
+		ApplyActions(events, player.Play(step, NewSimView(sim)))
+		sim.Update(step)
+		cheevos.Update(step)
+		events.Poll()
+	}
+	for _, p := range sim.Planets {
+		if p.IsAlive() {
+			result.Survivors++
+			survival += p.Age
+		}
+	}
+	result.PeakPopulation = sim.GetMaxPopulation()
+	result.Launched = sim.PlanetsLaunched
+	if result.Launched > 0 {
+		result.MeanSurvival = survival / time.Duration(result.Launched)
+	}
+	cheevos.Delete()
+	events.Release(&result)
+	return
+}
+
+// Plays a game for each of count seeds from first, spread over workers
+// goroutines. Results are in seed order.
+func RunBatch(first int64, count, workers int, field twodee.Rectangle, length time.Duration, newPlayer func(seed int64) Player) []BatchResult {
+	var (
+		results = make([]BatchResult, count)
+		indices = make(chan int)
+		wg      sync.WaitGroup
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indices {
+				var seed = first + int64(i)
+				results[i] = RunHeadless(seed, field, length, newPlayer(seed))
+			}
+		}()
+	}
+	for i := 0; i < count; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+	return results
+}
+
+func WriteBatchCSV(w io.Writer, results []BatchResult) error {
+	var out = csv.NewWriter(w)
+	out.Write([]string{
+		"seed",
+		"peak_population",
+		"launched",
+		"lost_to_fire",
+		"lost_to_collision",
+		"lost_to_void",
+		"survivors",
+		"mean_survival_s",
+		"cheevos_earned",
+		"cheevos_failed",
+		"cheevo_completion",
+	})
+	for _, r := range results {
+		var completion = 0.0
+		if attempted := r.CheevosEarned + r.CheevosFailed; attempted > 0 {
+			completion = float64(r.CheevosEarned) / float64(attempted)
+		}
+		out.Write([]string{
+			fmt.Sprintf("%d", r.Seed),
+			fmt.Sprintf("%d", r.PeakPopulation),
+			fmt.Sprintf("%d", r.Launched),
+			fmt.Sprintf("%d", r.LostToFire),
+			fmt.Sprintf("%d", r.LostToCollision),
+			fmt.Sprintf("%d", r.LostToVoid),
+			fmt.Sprintf("%d", r.Survivors),
+			fmt.Sprintf("%.1f", r.MeanSurvival.Seconds()),
+			fmt.Sprintf("%d", r.CheevosEarned),
+			fmt.Sprintf("%d", r.CheevosFailed),
+			fmt.Sprintf("%.3f", completion),
+		})
+	}
+	out.Flush()
+	return out.Error()
+}
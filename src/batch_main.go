@@ -0,0 +1,86 @@
+//go:build batch
+// +build batch
+
+// The batch command plays many headless games and writes their results as
+// CSV. It is built from the simulation files alone, without the window, GL
+// and audio code of the game:
+//
+//	go build -tags batch -o sol-batch ./src
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+)
+
+func main() {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %v [-seeds N] [-scenario FILE] [-out FILE] ...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	if err := batchCommand(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func batchCommand() (err error) {
+	var (
+		seeds      = flag.Int("seeds", 1000, "number of games to play")
+		first      = flag.Int64("first", 1, "seed of the first game")
+		workers    = flag.Int("workers", runtime.NumCPU(), "games played at once")
+		length     = flag.Duration("length", startDur, "length of each game")
+		fieldScale = flag.Float64("field", 1.0, "size of the playing field relative to the screen")
+		bot        = flag.String("bot", "circular", fmt.Sprintf("bot that launches planets, one of %v", BotNames()))
+		scenario   = flag.String("scenario", "", "JSON file of launches to play instead of a bot")
+		outPath    = flag.String("out", "", "CSV file to write, instead of stdout")
+		scale      float32
+		newPlayer  func(seed int64) Player
+		out        io.Writer
+	)
+	flag.Parse()
+	if *seeds < 1 {
+		return fmt.Errorf("Number of seeds must be at least 1, not %v", *seeds)
+	}
+	if scale, err = CheckFieldScale(*fieldScale); err != nil {
+		return
+	}
+	if *scenario != "" {
+		var s *Scenario
+		if s, err = LoadScenario(*scenario); err != nil {
+			return
+		}
+		newPlayer = func(seed int64) Player { return NewScenarioPlayer(s) }
+	} else {
+		if _, err = NewBot(*bot, 0); err != nil {
+			return
+		}
+		newPlayer = func(seed int64) Player {
+			var player, _ = NewBot(*bot, seed)
+			return player
+		}
+	}
+	if *workers < 1 {
+		*workers = 1
+	}
+	runtime.GOMAXPROCS(*workers)
+	out = os.Stdout
+	if *outPath != "" {
+		var file *os.File
+		if file, err = os.Create(*outPath); err != nil {
+			return
+		}
+		// Closing can fail too, and the results are lost if it does.
+		defer func() {
+			if closeErr := file.Close(); err == nil {
+				err = closeErr
+			}
+		}()
+		out = file
+	}
+	var results = RunBatch(*first, *seeds, *workers, FieldBounds(scale), *length, newPlayer)
+	return WriteBatchCSV(out, results)
+}
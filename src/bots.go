@@ -0,0 +1,171 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// How often bots consider launching a planet.
+	botInterval = 5 * time.Second
+	// Bots keep at most this many planets alive.
+	botMaxPlanets = 5
+	// The greedy bot leaves at least this much room between orbits.
+	greedyMinGap = 2.5
+)
+
+var bots = map[string]func(r *rand.Rand) Player{
+	"random":   func(r *rand.Rand) Player { return &RandomBot{rand: r} },
+	"circular": func(r *rand.Rand) Player { return &CircularBot{rand: r} },
+	"greedy":   func(r *rand.Rand) Player { return &GreedyBot{rand: r} },
+}
+
+// Returns the names NewBot accepts.
+func BotNames() (names []string) {
+	for name := range bots {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+func NewBot(name string, seed int64) (player Player, err error) {
+	var newBot, ok = bots[name]
+	if !ok {
+		err = fmt.Errorf("Unknown bot %v, expected one of %v", name, BotNames())
+		return
+	}
+	return newBot(rand.New(rand.NewSource(seed))), nil
+}
+
+// botTimer tells a bot when it is time to think again.
+type botTimer struct {
+	elapsed time.Duration
+}
+
+func (t *botTimer) ready(elapsed time.Duration) bool {
+	t.elapsed += elapsed
+	if t.elapsed < botInterval {
+		return false
+	}
+	t.elapsed -= botInterval
+	return true
+}
+
+// Returns a point at distance r and angle a from the sun.
+func aroundSun(view SimView, r, a float64) twodee.Point {
+	return view.Sun().Pos.Add(twodee.Pt(float32(r*math.Cos(a)), float32(r*math.Sin(a))))
+}
+
+// Returns the actions to launch a planet onto a circular orbit from pt, with
+// the speed off by a random few percent, as nobody drags perfectly.
+func circularLaunch(view SimView, r *rand.Rand, pt twodee.Point) []PlayerAction {
+	var (
+		end    = view.CircularDragEnd(pt)
+		wobble = 1 + float32(r.NormFloat64()*0.05)
+	)
+	return []PlayerAction{Drop(pt), Release(pt.Add(end.Sub(pt).Scale(wobble)))}
+}
+
+// RandomBot throws planets anywhere, in any direction.
+type RandomBot struct {
+	botTimer
+	rand *rand.Rand
+}
+
+func (b *RandomBot) Play(elapsed time.Duration, view SimView) []PlayerAction {
+	if !b.ready(elapsed) || len(view.Planets()) >= botMaxPlanets {
+		return nil
+	}
+	var (
+		bounds = view.Bounds()
+		pt     = twodee.Pt(
+			bounds.Min.X+b.rand.Float32()*(bounds.Max.X-bounds.Min.X),
+			bounds.Min.Y+b.rand.Float32()*(bounds.Max.Y-bounds.Min.Y),
+		)
+		// A drag of up to a few units, like a hurried player.
+		drag = twodee.Pt(float32(b.rand.NormFloat64()*4), float32(b.rand.NormFloat64()*4))
+	)
+	return []PlayerAction{Drop(pt), Release(pt.Add(drag))}
+}
+
+// CircularBot launches planets onto roughly circular orbits somewhere in the
+// life zone, as a steady player would.
+type CircularBot struct {
+	botTimer
+	rand *rand.Rand
+}
+
+func (b *CircularBot) Play(elapsed time.Duration, view SimView) []PlayerAction {
+	if !b.ready(elapsed) || len(view.Planets()) >= botMaxPlanets {
+		return nil
+	}
+	var (
+		r = TooCloseDist + b.rand.Float64()*(TooFarDist-TooCloseDist)
+		a = b.rand.Float64() * 2 * math.Pi
+	)
+	return circularLaunch(view, b.rand, aroundSun(view, r, a))
+}
+
+// GreedyBot fills the life zone, putting each planet on a circular orbit in
+// the widest gap between the orbits already there.
+type GreedyBot struct {
+	botTimer
+	rand *rand.Rand
+}
+
+func (b *GreedyBot) Play(elapsed time.Duration, view SimView) []PlayerAction {
+	if !b.ready(elapsed) {
+		return nil
+	}
+	var (
+		sun     = view.Sun().Pos
+		planets = view.Planets()
+		radii   = []float64{}
+		bestR   = 0.0
+		bestGap = 0.0
+	)
+	for _, p := range planets {
+		radii = append(radii, float64(p.Pos.DistanceTo(sun)))
+	}
+	// Try radii across the life zone, keeping clear of its edges.
+	for i := 0; i <= 16; i++ {
+		var (
+			r   = TooCloseDist + 1 + float64(i)*(TooFarDist-TooCloseDist-2)/16
+			gap = math.Inf(1)
+		)
+		for _, other := range radii {
+			gap = math.Min(gap, math.Abs(r-other))
+		}
+		if gap > bestGap {
+			bestR, bestGap = r, gap
+		}
+	}
+	if bestGap < greedyMinGap {
+		return nil
+	}
+	// Launch as far as possible from every planet, to avoid collisions.
+	var (
+		bestA    = 0.0
+		bestDist = -1.0
+	)
+	for i := 0; i < 12; i++ {
+		var (
+			a    = float64(i) * 2 * math.Pi / 12
+			pt   = aroundSun(view, bestR, a)
+			dist = math.Inf(1)
+		)
+		for _, p := range planets {
+			dist = math.Min(dist, float64(p.Pos.DistanceTo(pt)))
+		}
+		if dist > bestDist {
+			bestA, bestDist = a, dist
+		}
+	}
+	return circularLaunch(view, b.rand, aroundSun(view, bestR, bestA))
+}
@@ -0,0 +1,107 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	twodee "../libs/twodee"
+)
+
+const (
+	MaxZoom  float32 = 4.0
+	ZoomStep float32 = 1.1
+	// Keyboard panning speed in world units per second at 1x zoom.
+	PanSpeed float32 = 40.0
+)
+
+type FollowMode int
+
+const (
+	FollowNone FollowMode = iota
+	FollowPlanet
+	FollowSun
+	FollowBarycentre
+)
+
+// Camera describes which part of the world is on screen.
+type Camera struct {
+	Center  twodee.Point
+	Zoom    float32
+	view    twodee.Rectangle
+	limits  twodee.Rectangle
+	minZoom float32
+}
+
+// The view rectangle is what the camera shows at 1x zoom. The camera will not
+// zoom out beyond the limits, nor pan its centre outside them.
+func NewCamera(view, limits twodee.Rectangle) (c *Camera) {
+	c = &Camera{
+		Center: twodee.Pt(
+			(view.Min.X+view.Max.X)/2.0,
+			(view.Min.Y+view.Max.Y)/2.0,
+		),
+		Zoom:   1.0,
+		view:   view,
+		limits: limits,
+	}
+	c.minZoom = (view.Max.X - view.Min.X) / (limits.Max.X - limits.Min.X)
+	if h := (view.Max.Y - view.Min.Y) / (limits.Max.Y - limits.Min.Y); h > c.minZoom {
+		c.minZoom = h
+	}
+	if c.minZoom > 1.0 {
+		c.minZoom = 1.0
+	}
+	return
+}
+
+func (c *Camera) Bounds() twodee.Rectangle {
+	var (
+		hw = (c.view.Max.X - c.view.Min.X) / (2.0 * c.Zoom)
+		hh = (c.view.Max.Y - c.view.Min.Y) / (2.0 * c.Zoom)
+	)
+	return twodee.Rect(c.Center.X-hw, c.Center.Y-hh, c.Center.X+hw, c.Center.Y+hh)
+}
+
+func (c *Camera) Pan(dx, dy float32) {
+	c.Center = twodee.Pt(c.Center.X+dx, c.Center.Y+dy)
+	c.clamp()
+}
+
+func (c *Camera) LookAt(pt twodee.Point) {
+	c.Center = pt
+	c.clamp()
+}
+
+// Zooms by factor while keeping the world point pt at the same spot on
+// screen.
+func (c *Camera) ZoomAt(factor float32, pt twodee.Point) {
+	var zoom = c.Zoom * factor
+	if zoom < c.minZoom {
+		zoom = c.minZoom
+	}
+	if zoom > MaxZoom {
+		zoom = MaxZoom
+	}
+	factor = zoom / c.Zoom
+	c.Zoom = zoom
+	c.Center = twodee.Pt(
+		pt.X+(c.Center.X-pt.X)/factor,
+		pt.Y+(c.Center.Y-pt.Y)/factor,
+	)
+	c.clamp()
+}
+
+func (c *Camera) clamp() {
+	if c.Center.X < c.limits.Min.X {
+		c.Center.X = c.limits.Min.X
+	}
+	if c.Center.X > c.limits.Max.X {
+		c.Center.X = c.limits.Max.X
+	}
+	if c.Center.Y < c.limits.Min.Y {
+		c.Center.Y = c.limits.Min.Y
+	}
+	if c.Center.Y > c.limits.Max.Y {
+		c.Center.Y = c.limits.Max.Y
+	}
+}
@@ -0,0 +1,307 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"sort"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	censusLineHeight = 22
+	censusPadding    = 8
+	// Distance of the panel from the top of the screen, clear of the
+	// population count and sparkline.
+	censusTop = 85
+	// Keep clear of the selected planet's details in the bottom left.
+	censusBottom = 200
+	// Samples over which the growth trend is measured.
+	censusTrendSamples = 5
+)
+
+var (
+	censusBackgroundColor = color.RGBA{0, 0, 0, 170}
+	censusSelectedColor   = color.RGBA{255, 240, 120, 60}
+)
+
+type censusColumn struct {
+	Title string
+	Width float32
+	cell  func(r *censusRow) string
+	less  func(a, b *censusRow) bool
+}
+
+type censusRow struct {
+	Planet *PlanetaryBody
+	Trend  int
+}
+
+var censusColumns = []censusColumn{
+	censusColumn{"NAME", 150,
+		func(r *censusRow) string { return r.Planet.Name },
+		func(a, b *censusRow) bool { return a.Planet.Name < b.Planet.Name },
+	},
+	censusColumn{"TYPE", 80,
+		func(r *censusRow) string { return planetType(r.Planet) },
+		func(a, b *censusRow) bool { return a.Planet.Scale < b.Planet.Scale },
+	},
+	censusColumn{"STATE", 100,
+		func(r *censusRow) string { return planetStateName(r.Planet) },
+		func(a, b *censusRow) bool { return planetStateName(a.Planet) < planetStateName(b.Planet) },
+	},
+	censusColumn{"POP", 90,
+		func(r *censusRow) string { return fmt.Sprintf("%d", r.Planet.GetPopulation()) },
+		func(a, b *censusRow) bool { return a.Planet.Population < b.Planet.Population },
+	},
+	censusColumn{"TREND", 80,
+		func(r *censusRow) string { return fmt.Sprintf("%+d", r.Trend) },
+		func(a, b *censusRow) bool { return a.Trend < b.Trend },
+	},
+	censusColumn{"TEMP", 70,
+		func(r *censusRow) string { return fmt.Sprintf("%d°F", r.Planet.GetTemperature()) },
+		func(a, b *censusRow) bool { return a.Planet.Temperature < b.Planet.Temperature },
+	},
+	censusColumn{"AGE", 60,
+		func(r *censusRow) string { return fmt.Sprintf("%ds", int64(r.Planet.Age.Seconds())) },
+		func(a, b *censusRow) bool { return a.Planet.Age < b.Planet.Age },
+	},
+}
+
+func planetType(p *PlanetaryBody) string {
+	switch {
+	case p.Scale < 0.35:
+		return "DWARF"
+	case p.Scale < 0.55:
+		return "TERRAN"
+	default:
+		return "GIANT"
+	}
+}
+
+func planetStateName(p *PlanetaryBody) string {
+	switch {
+	case p.HasState(Dying):
+		return "DYING"
+	case p.HasState(TooClose):
+		return "TOO HOT"
+	case p.HasState(TooFar):
+		return "TOO COLD"
+	case p.HasState(Fertile):
+		return "FERTILE"
+	default:
+		return "BARREN"
+	}
+}
+
+type censusRows struct {
+	rows   []*censusRow
+	column int
+	desc   bool
+}
+
+func (s censusRows) Len() int      { return len(s.rows) }
+func (s censusRows) Swap(i, j int) { s.rows[i], s.rows[j] = s.rows[j], s.rows[i] }
+func (s censusRows) Less(i, j int) bool {
+	if s.desc {
+		return censusColumns[s.column].less(s.rows[j], s.rows[i])
+	}
+	return censusColumns[s.column].less(s.rows[i], s.rows[j])
+}
+
+// CensusPanel lists every planet in a table down the left of the screen.
+// Clicking a heading sorts by that column and clicking a row selects the
+// planet.
+type CensusPanel struct {
+	Visible     bool
+	font        *twodee.FontFace
+	headerFont  *twodee.FontFace
+	headerCache map[int]*twodee.TextCache
+	cellCache   map[int]*twodee.TextCache
+	moreCache   *twodee.TextCache
+	bounds      twodee.Rectangle
+	rows        []*censusRow
+	sortColumn  int
+	sortDesc    bool
+}
+
+func NewCensusPanel(bounds twodee.Rectangle) (panel *CensusPanel, err error) {
+	var (
+		font       *twodee.FontFace
+		headerFont *twodee.FontFace
+		bg         = color.Transparent
+		exoFont    = "assets/fonts/Exo-SemiBold.ttf"
+	)
+	if font, err = twodee.NewFontFace(exoFont, 18, regColor, bg); err != nil {
+		return
+	}
+	if headerFont, err = twodee.NewFontFace(exoFont, 18, hiColor, bg); err != nil {
+		return
+	}
+	panel = &CensusPanel{
+		Visible:     false,
+		font:        font,
+		headerFont:  headerFont,
+		headerCache: map[int]*twodee.TextCache{},
+		cellCache:   map[int]*twodee.TextCache{},
+		moreCache:   twodee.NewTextCache(font),
+		bounds:      bounds,
+		rows:        []*censusRow{},
+		sortColumn:  0,
+		sortDesc:    false,
+	}
+	return
+}
+
+func (c *CensusPanel) Delete() {
+	for _, v := range c.headerCache {
+		v.Delete()
+	}
+	for _, v := range c.cellCache {
+		v.Delete()
+	}
+	c.headerCache = map[int]*twodee.TextCache{}
+	c.cellCache = map[int]*twodee.TextCache{}
+	c.moreCache.Delete()
+}
+
+func (c *CensusPanel) Toggle() {
+	c.Visible = !c.Visible
+}
+
+// Sorts by column, or reverses the order if already sorted by it.
+func (c *CensusPanel) SortBy(column int) {
+	if column == c.sortColumn {
+		c.sortDesc = !c.sortDesc
+		return
+	}
+	c.sortColumn = column
+	c.sortDesc = false
+}
+
+func (c *CensusPanel) width() (w float32) {
+	for _, col := range censusColumns {
+		w += col.Width
+	}
+	return w + 2*censusPadding
+}
+
+func (c *CensusPanel) top() float32 {
+	return c.bounds.Max.Y - censusTop
+}
+
+// Returns the number of planets that fit in the table.
+func (c *CensusPanel) maxRows() int {
+	return int((c.top()-censusBottom)/censusLineHeight) - 2
+}
+
+func (c *CensusPanel) update(sim *Simulation) {
+	c.rows = c.rows[:0]
+	for _, p := range sim.Planets {
+		if !p.IsAlive() {
+			continue
+		}
+		c.rows = append(c.rows, &censusRow{p, sim.History.Trend(p.Id, censusTrendSamples)})
+	}
+	sort.Stable(censusRows{c.rows, c.sortColumn, c.sortDesc})
+}
+
+func (c *CensusPanel) Render(text *twodee.TextRenderer, shapes *ShapeRenderer, sim *Simulation, selectedId int) {
+	c.update(sim)
+	var (
+		top    = c.top()
+		rows   = c.rows
+		height = float32(censusLineHeight * (len(rows) + 1))
+	)
+	if len(rows) > c.maxRows() {
+		rows = rows[:c.maxRows()]
+		height = float32(censusLineHeight * (len(rows) + 2))
+	}
+	shapes.Bind()
+	shapes.DrawTriangles(RectangleVertices(twodee.Rect(0, top-height-censusPadding, c.width(), top), censusBackgroundColor))
+	for i, row := range rows {
+		if row.Planet.Id == selectedId {
+			var y = top - float32(censusLineHeight*(i+2))
+			shapes.DrawTriangles(RectangleVertices(twodee.Rect(0, y, c.width(), y+censusLineHeight), censusSelectedColor))
+		}
+	}
+	shapes.Unbind()
+
+	text.Bind()
+	c.drawRow(text, c.headerFont, c.headerCache, 0, c.headers(), top-censusLineHeight)
+	for i, row := range rows {
+		var cells = make([]string, len(censusColumns))
+		for j, col := range censusColumns {
+			cells[j] = col.cell(row)
+		}
+		c.drawRow(text, c.font, c.cellCache, i*len(censusColumns), cells, top-float32(censusLineHeight*(i+2)))
+	}
+	if len(c.rows) > len(rows) {
+		c.moreCache.SetText(fmt.Sprintf("+%d MORE", len(c.rows)-len(rows)))
+		if c.moreCache.Texture != nil {
+			text.Draw(c.moreCache.Texture, censusPadding, top-height)
+		}
+	}
+	text.Unbind()
+}
+
+func (c *CensusPanel) headers() []string {
+	var headers = make([]string, len(censusColumns))
+	for i, col := range censusColumns {
+		headers[i] = col.Title
+		if i == c.sortColumn && c.sortDesc {
+			headers[i] += " -"
+		} else if i == c.sortColumn {
+			headers[i] += " +"
+		}
+	}
+	return headers
+}
+
+func (c *CensusPanel) drawRow(text *twodee.TextRenderer, font *twodee.FontFace, caches map[int]*twodee.TextCache, key int, cells []string, y float32) {
+	var (
+		x         float32 = censusPadding
+		textCache *twodee.TextCache
+		ok        bool
+	)
+	for i, cell := range cells {
+		if textCache, ok = caches[key+i]; !ok {
+			textCache = twodee.NewTextCache(font)
+			caches[key+i] = textCache
+		}
+		textCache.SetText(cell)
+		if textCache.Texture != nil {
+			text.Draw(textCache.Texture, x, y)
+		}
+		x += censusColumns[i].Width
+	}
+}
+
+// Handles a click at screen coordinates with the origin in the bottom left.
+// Returns the planet clicked on, if any, and whether the click hit the panel.
+func (c *CensusPanel) Click(x, y float32) (planet *PlanetaryBody, hit bool) {
+	var (
+		top  = c.top()
+		line = int((top - y) / censusLineHeight)
+	)
+	if x < 0 || x > c.width() || y > top || line > len(c.rows) || line > c.maxRows() {
+		return
+	}
+	hit = true
+	if line == 0 {
+		var left float32 = censusPadding
+		for i, col := range censusColumns {
+			if x < left+col.Width {
+				c.SortBy(i)
+				break
+			}
+			left += col.Width
+		}
+		return
+	}
+	planet = c.rows[line-1].Planet
+	return
+}
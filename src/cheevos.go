@@ -7,7 +7,7 @@ import (
 )
 
 type Cheevos struct {
-	events  *twodee.GameEventHandler
+	events  *EventBus
 	queue   []Cheevo
 	sim     *Simulation
 	active  Cheevo
@@ -16,7 +16,7 @@ type Cheevos struct {
 	Passed  []string
 }
 
-func NewCheevos(events *twodee.GameEventHandler, sim *Simulation) *Cheevos {
+func NewCheevos(events *EventBus, sim *Simulation) *Cheevos {
 	return &Cheevos{
 		Passed: []string{},
 		events: events,
@@ -46,9 +46,11 @@ func (c *Cheevos) Update(elapsed time.Duration) {
 			c.active.Success(c.events)
 			c.active.SetDone()
 			c.Passed = append(c.Passed, c.active.GetLabel())
+			c.events.Enqueue(NewCheevoEvent(CheevoSuccess, c.active.GetLabel()))
 		} else if c.active.IsFailure(c.sim) && !c.active.IsDone() {
 			c.active.Failure(c.events)
 			c.active.SetDone()
+			c.events.Enqueue(NewCheevoEvent(CheevoFailure, c.active.GetLabel()))
 		}
 		if c.active.IsReadyToDelete() {
 			c.active.Delete()
@@ -78,13 +80,71 @@ func (c *Cheevos) selectActive() {
 	}
 }
 
+// SkipTutorial drops the introductory cheevo for players who have already
+// been through it.
+func (c *Cheevos) SkipTutorial() {
+	for i, candidate := range c.queue {
+		if _, ok := candidate.(*MakeFirstPlanet); ok {
+			c.queue = append(c.queue[:i], c.queue[i+1:]...)
+			return
+		}
+	}
+}
+
+type CheevosSnapshot struct {
+	queue    []Cheevo
+	active   Cheevo
+	counter  time.Duration
+	passed   []string
+	restores []func()
+}
+
+func (c *Cheevos) Snapshot() *CheevosSnapshot {
+	var snap = &CheevosSnapshot{
+		queue:    append([]Cheevo{}, c.queue...),
+		active:   c.active,
+		counter:  c.counter,
+		passed:   append([]string{}, c.Passed...),
+		restores: []func(){},
+	}
+	for _, cheevo := range snap.queue {
+		snap.restores = append(snap.restores, cheevo.Save())
+	}
+	if c.active != nil {
+		snap.restores = append(snap.restores, c.active.Save())
+	}
+	return snap
+}
+
+func (c *Cheevos) Restore(snap *CheevosSnapshot) {
+	var resume = snap.active != nil && snap.active != c.active
+	if c.active != nil && c.active != snap.active {
+		// Started since the snapshot; it goes back into the queue.
+		c.active.Delete()
+	}
+	for _, restore := range snap.restores {
+		restore()
+	}
+	c.queue = append([]Cheevo{}, snap.queue...)
+	c.active = snap.active
+	c.counter = snap.counter
+	c.Passed = append([]string{}, snap.passed...)
+	if resume {
+		// Finished since the snapshot, so it was deleted.
+		c.active.Resume(c.events)
+	}
+}
+
 func (c *Cheevos) Delete() {
+	if c.active != nil {
+		c.active.Delete()
+	}
 }
 
 type Cheevo interface {
-	Init(events *twodee.GameEventHandler)
-	Success(events *twodee.GameEventHandler)
-	Failure(events *twodee.GameEventHandler)
+	Init(events *EventBus)
+	Success(events *EventBus)
+	Failure(events *EventBus)
 	IsDone() bool
 	SetDone()
 	IsAvailable(sim *Simulation) bool
@@ -95,6 +155,10 @@ type Cheevo interface {
 	GetElapsed() time.Duration
 	Update(elapsed time.Duration)
 	Delete()
+	// Returns a function which puts the cheevo back into its current state.
+	Save() func()
+	// Re-registers anything Delete released after a restore.
+	Resume(events *EventBus)
 }
 
 type BaseCheevo struct {
@@ -153,13 +217,36 @@ func (c *BaseCheevo) Delete() {
 	c.ClearCallbacks()
 }
 
-func (c *BaseCheevo) sendMessage(msg string, events *twodee.GameEventHandler) func() {
+func (c *BaseCheevo) Save() func() {
+	var (
+		done      = c.done
+		elapsed   = c.elapsed
+		callbacks = make([]Callback, len(c.callbacks))
+	)
+	for i, cb := range c.callbacks {
+		callbacks[i] = *cb
+	}
+	return func() {
+		c.done = done
+		c.elapsed = elapsed
+		c.callbacks = make([]*Callback, len(callbacks))
+		for i := range callbacks {
+			var cb = callbacks[i]
+			c.callbacks[i] = &cb
+		}
+	}
+}
+
+func (c *BaseCheevo) Resume(events *EventBus) {
+}
+
+func (c *BaseCheevo) sendMessage(msg string, events *EventBus) func() {
 	return func() {
 		events.Enqueue(NewMessageEvent(msg))
 	}
 }
 
-func (c *BaseCheevo) SendMessages(messages []string, events *twodee.GameEventHandler) {
+func (c *BaseCheevo) SendMessages(messages []string, events *EventBus) {
 	var counter time.Duration = 0
 	for i := 0; i < len(messages); i++ {
 		c.After(counter, c.sendMessage(messages[i], events))
@@ -186,13 +273,15 @@ func (c *BaseCheevo) IsFailure(sim *Simulation) bool {
 	return c.elapsed > c.expires
 }
 
-func (c *BaseCheevo) Failure(events *twodee.GameEventHandler) {
+func (c *BaseCheevo) Failure(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{"DARN, THAT TOOK TOO LONG"}, events)
 }
 
 // MAKE THE FIRST PLANET =======================================================
 
+const MakeFirstPlanetLabel = "MADE YOUR FIRST PLANET"
+
 type MakeFirstPlanet struct {
 	*BaseCheevo
 	hasCreated bool
@@ -202,7 +291,7 @@ type MakeFirstPlanet struct {
 func NewMakeFirstPlanet() Cheevo {
 	return &MakeFirstPlanet{
 		BaseCheevo: newBaseCheevo(
-			"MADE YOUR FIRST PLANET",
+			MakeFirstPlanetLabel,
 			30*time.Second),
 		hasCreated: false,
 		introText: []string{
@@ -217,17 +306,28 @@ func NewMakeFirstPlanet() Cheevo {
 	}
 }
 
-func (c *MakeFirstPlanet) Init(events *twodee.GameEventHandler) {
+func (c *MakeFirstPlanet) Init(events *EventBus) {
 	c.SendMessages(c.introText, events)
 }
 
-func (c *MakeFirstPlanet) Success(events *twodee.GameEventHandler) {
+func (c *MakeFirstPlanet) Success(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		"WONDERFUL!",
 	}, events)
 }
 
+func (c *MakeFirstPlanet) Save() func() {
+	var (
+		base       = c.BaseCheevo.Save()
+		hasCreated = c.hasCreated
+	)
+	return func() {
+		base()
+		c.hasCreated = hasCreated
+	}
+}
+
 func (c *MakeFirstPlanet) IsAvailable(sim *Simulation) bool {
 	return true
 }
@@ -264,17 +364,30 @@ func NewKeepPlanetAlive(seconds int) Cheevo {
 	}
 }
 
-func (c *KeepPlanetAlive) Init(events *twodee.GameEventHandler) {
+func (c *KeepPlanetAlive) Init(events *EventBus) {
 	c.SendMessages(c.introText, events)
 }
 
-func (c *KeepPlanetAlive) Success(events *twodee.GameEventHandler) {
+func (c *KeepPlanetAlive) Success(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		fmt.Sprintf("GREAT! %v HAS LIVED A LONG TIME", c.planetName),
 	}, events)
 }
 
+func (c *KeepPlanetAlive) Save() func() {
+	var (
+		base       = c.BaseCheevo.Save()
+		hasPassed  = c.hasPassed
+		planetName = c.planetName
+	)
+	return func() {
+		base()
+		c.hasPassed = hasPassed
+		c.planetName = planetName
+	}
+}
+
 func (c *KeepPlanetAlive) IsAvailable(sim *Simulation) bool {
 	for _, p := range sim.Planets {
 		if p.Age > c.threshold {
@@ -322,17 +435,30 @@ func NewPlanetVelocity(velocity float32) Cheevo {
 	}
 }
 
-func (c *PlanetVelocity) Init(events *twodee.GameEventHandler) {
+func (c *PlanetVelocity) Init(events *EventBus) {
 	c.SendMessages(c.introText, events)
 }
 
-func (c *PlanetVelocity) Success(events *twodee.GameEventHandler) {
+func (c *PlanetVelocity) Success(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		fmt.Sprintf("WOAH! %v IS A SPEEDY ONE", c.planetName),
 	}, events)
 }
 
+func (c *PlanetVelocity) Save() func() {
+	var (
+		base       = c.BaseCheevo.Save()
+		hasPassed  = c.hasPassed
+		planetName = c.planetName
+	)
+	return func() {
+		base()
+		c.hasPassed = hasPassed
+		c.planetName = planetName
+	}
+}
+
 func (c *PlanetVelocity) IsAvailable(sim *Simulation) bool {
 	return true
 }
@@ -377,17 +503,28 @@ func NewMultiPlanets(count int32, seconds int32) Cheevo {
 	}
 }
 
-func (c *MultiPlanets) Init(events *twodee.GameEventHandler) {
+func (c *MultiPlanets) Init(events *EventBus) {
 	c.SendMessages(c.introText, events)
 }
 
-func (c *MultiPlanets) Success(events *twodee.GameEventHandler) {
+func (c *MultiPlanets) Success(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		"SO MANY PLANETS!",
 	}, events)
 }
 
+func (c *MultiPlanets) Save() func() {
+	var (
+		base      = c.BaseCheevo.Save()
+		hasPassed = c.hasPassed
+	)
+	return func() {
+		base()
+		c.hasPassed = hasPassed
+	}
+}
+
 func (c *MultiPlanets) IsAvailable(sim *Simulation) bool {
 	return len(sim.Planets) < int(c.planetCount)
 }
@@ -431,17 +568,28 @@ func NewTotalPopulation(population int32) Cheevo {
 	}
 }
 
-func (c *TotalPopulation) Init(events *twodee.GameEventHandler) {
+func (c *TotalPopulation) Init(events *EventBus) {
 	c.SendMessages(c.introText, events)
 }
 
-func (c *TotalPopulation) Success(events *twodee.GameEventHandler) {
+func (c *TotalPopulation) Success(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		fmt.Sprintf("I FEEL THE WARMTH OF %v TINY BODIES", c.population),
 	}, events)
 }
 
+func (c *TotalPopulation) Save() func() {
+	var (
+		base      = c.BaseCheevo.Save()
+		hasPassed = c.hasPassed
+	)
+	return func() {
+		base()
+		c.hasPassed = hasPassed
+	}
+}
+
 func (c *TotalPopulation) IsAvailable(sim *Simulation) bool {
 	return sim.GetPopulation() < int(c.population)
 }
@@ -463,11 +611,9 @@ type Sacrifice struct {
 	hasPassed  bool
 	hasFailed  bool
 	population int32
-	target     *PlanetaryBody
+	targetId   int
 	planetName string
-	events     *twodee.GameEventHandler
-	obsFire    int
-	obsColl    int
+	events     *EventBus
 }
 
 func NewSacrifice(population int32) Cheevo {
@@ -481,53 +627,68 @@ func NewSacrifice(population int32) Cheevo {
 	}
 }
 
-func (c *Sacrifice) Init(events *twodee.GameEventHandler) {
+func (c *Sacrifice) Init(events *EventBus) {
 	c.SendMessages([]string{
 		"I AM UNFULFILLED",
 		"YOU HAVE BROUGHT SO MANY SOULS TO ME",
 		fmt.Sprintf("BRING %v INTO MY GREATNESS", c.planetName),
 	}, events)
-	c.obsFire = events.AddObserver(PlanetFireDeath, c.OnFireDeath)
-	c.obsColl = events.AddObserver(PlanetCollision, c.OnCollision)
-	c.events = events
+	c.Resume(events)
 }
 
-func (c *Sacrifice) OnFireDeath(evt twodee.GETyper) {
-	switch event := evt.(type) {
-	case *PlanetEvent:
-		if event.Planet == c.target {
-			c.hasPassed = true
-		}
+func (c *Sacrifice) OnFireDeath(event *PlanetEvent) {
+	if event.PlanetId == c.targetId {
+		c.hasPassed = true
 	}
 }
 
-func (c *Sacrifice) OnCollision(evt twodee.GETyper) {
-	switch event := evt.(type) {
-	case *PlanetEvent:
-		if event.Planet == c.target {
-			c.hasFailed = true
-		}
+func (c *Sacrifice) OnCollision(event *PlanetEvent) {
+	if event.PlanetId == c.targetId {
+		c.hasFailed = true
 	}
 }
 
-func (c *Sacrifice) Failure(events *twodee.GameEventHandler) {
+func (c *Sacrifice) Failure(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		"I AM AN ANGRY SOL!",
 	}, events)
 }
 
-func (c *Sacrifice) Success(events *twodee.GameEventHandler) {
+func (c *Sacrifice) Success(events *EventBus) {
 	c.ClearCallbacks()
 	c.SendMessages([]string{
 		fmt.Sprintf("%v IS MINE!", c.planetName),
 	}, events)
 }
 
+func (c *Sacrifice) Save() func() {
+	var (
+		base       = c.BaseCheevo.Save()
+		hasPassed  = c.hasPassed
+		hasFailed  = c.hasFailed
+		targetId   = c.targetId
+		planetName = c.planetName
+	)
+	return func() {
+		base()
+		c.hasPassed = hasPassed
+		c.hasFailed = hasFailed
+		c.targetId = targetId
+		c.planetName = planetName
+	}
+}
+
+func (c *Sacrifice) Resume(events *EventBus) {
+	events.OnPlanet(c, PlanetFireDeath, c.OnFireDeath)
+	events.OnPlanet(c, PlanetCollision, c.OnCollision)
+	c.events = events
+}
+
 func (c *Sacrifice) IsAvailable(sim *Simulation) bool {
 	for _, p := range sim.Planets {
 		if int(p.Population) > int(c.population) {
-			c.target = p
+			c.targetId = p.Id
 			c.planetName = p.Name
 			return true
 		}
@@ -546,6 +707,8 @@ func (c *Sacrifice) IsFailure(sim *Simulation) bool {
 
 func (c *Sacrifice) Delete() {
 	c.BaseCheevo.Delete()
-	c.events.RemoveObserver(PlanetFireDeath, c.obsFire)
-	c.events.RemoveObserver(PlanetCollision, c.obsColl)
+	// Only set once the cheevo has started.
+	if c.events != nil {
+		c.events.Release(c)
+	}
 }
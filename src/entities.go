@@ -5,6 +5,7 @@ import (
 	"math"
 	"math/rand"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -22,6 +23,7 @@ const (
 	Dying
 	Dead
 	Phantom
+	Escaped
 )
 
 var PlanetaryAnimations = map[PlanetaryState][]int{
@@ -51,6 +53,9 @@ type PlanetaryBody struct {
 	Age              time.Duration
 	Rotation             float32
 	Name                 string
+	// Identifies the body for as long as the program runs; never reused.
+	// Ids count up from 1 in order of creation, so 0 means no body.
+	Id int
 }
 
 var PlanetNames = []string{
@@ -183,14 +188,28 @@ var PlanetNames = []string{
 	"Zardoz",
 }
 
-var PlanetNameIndex int = 0
+// NamePicker hands out planet names in a random order, not repeating any
+// until every name has been used.
+type NamePicker struct {
+	names []string
+	index int
+	rand  *rand.Rand
+}
 
-func SelectPlanetName() string {
-	var choice = rand.Intn(len(PlanetNames) - PlanetNameIndex)
-	var name = PlanetNames[choice]
-	PlanetNames[choice] = PlanetNames[PlanetNameIndex]
-	PlanetNames[PlanetNameIndex] = name
-	PlanetNameIndex = (PlanetNameIndex + 1) % len(PlanetNames)
+func NewNamePicker(r *rand.Rand) *NamePicker {
+	return &NamePicker{
+		names: append([]string{}, PlanetNames...),
+		index: 0,
+		rand:  r,
+	}
+}
+
+func (n *NamePicker) Next() string {
+	var choice = n.index + n.rand.Intn(len(n.names)-n.index)
+	var name = n.names[choice]
+	n.names[choice] = n.names[n.index]
+	n.names[n.index] = name
+	n.index = (n.index + 1) % len(n.names)
 	return strings.ToUpper(name)
 }
 
@@ -217,14 +236,22 @@ func NewSun() *PlanetaryBody {
 		Age:              0,
 		Rotation:             0,
 		Name:                 "Sol",
+		Id:                   nextBodyId(),
 	}
 	body.SetState(Sun)
 	return body
 }
 
-func NewPlanet(x, y float32) *PlanetaryBody {
+// Bodies are created by simulations running in parallel in batch runs.
+var lastBodyId int64 = 0
+
+func nextBodyId() int {
+	return int(atomic.AddInt64(&lastBodyId, 1))
+}
+
+func NewPlanet(x, y float32, r *rand.Rand, name string) *PlanetaryBody {
 	var (
-		scale  float32 = float32(math.Min(0.7, math.Max(0.2, rand.Float64())))
+		scale  float32 = float32(math.Min(0.7, math.Max(0.2, r.Float64())))
 		length float32 = 128.0 / PxPerUnit * scale
 	)
 	body := &PlanetaryBody{
@@ -245,8 +272,9 @@ func NewPlanet(x, y float32) *PlanetaryBody {
 		Scale:                scale,
 		DistToSun:            0.0,
 		Age:              0,
-		Rotation:             rand.Float32(),
-		Name:                 SelectPlanetName(),
+		Rotation:             r.Float32(),
+		Name:                 name,
+		Id:                   nextBodyId(),
 	}
 	body.SetState(Fertile)
 	body.MaxPopulation = body.Mass * 1000
@@ -342,6 +370,10 @@ func (p *PlanetaryBody) HasState(state PlanetaryState) bool {
 	return p.State&state == state
 }
 
+func (p *PlanetaryBody) IsAlive() bool {
+	return !p.HasState(Dying) && !p.HasState(Dead)
+}
+
 func (p *PlanetaryBody) RemState(state PlanetaryState) {
 	p.SetState(p.State & ^state)
 }
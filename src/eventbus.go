@@ -0,0 +1,214 @@
+package main
+
+import (
+	"log"
+	"sort"
+
+	twodee "../libs/twodee"
+)
+
+// Observers with a higher priority are called first. Observers of equal
+// priority are called in the order they subscribed.
+const (
+	PriorityLow    = -10
+	PriorityNormal = 0
+	PriorityHigh   = 10
+)
+
+// Observer is a subscription to one type of game event.
+type Observer struct {
+	eventType twodee.GameEventType
+	owner     interface{}
+	callback  twodee.GameEventCallback
+	priority  int
+	order     int
+	once      bool
+	removed   bool
+	bus       *EventBus
+}
+
+// Sets the observer's priority, returning it so that calls can be chained
+// onto Subscribe.
+func (o *Observer) Prioritize(priority int) *Observer {
+	o.priority = priority
+	o.bus.sort(o.eventType)
+	return o
+}
+
+// Makes the observer unsubscribe itself after its first event.
+func (o *Observer) Once() *Observer {
+	o.once = true
+	return o
+}
+
+func (o *Observer) Unsubscribe() {
+	o.bus.Unsubscribe(o)
+}
+
+type byDispatchOrder []*Observer
+
+func (s byDispatchOrder) Len() int      { return len(s) }
+func (s byDispatchOrder) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byDispatchOrder) Less(i, j int) bool {
+	if s[i].priority != s[j].priority {
+		return s[i].priority > s[j].priority
+	}
+	return s[i].order < s[j].order
+}
+
+// EventBus queues game events and hands them to observers in priority order.
+// Every observer belongs to an owner, and Release drops all of an owner's
+// observers at once, so Delete methods need not keep track of observer ids.
+type EventBus struct {
+	handler   *twodee.GameEventHandler
+	observers [NumGameEventTypes][]*Observer
+	order     int
+	// Log every event as it is dispatched.
+	Debug bool
+	// Returns the simulation tick for debug output.
+	Clock func() int
+}
+
+func NewEventBus(debug bool) *EventBus {
+	var bus = &EventBus{
+		handler: twodee.NewGameEventHandler(NumGameEventTypes),
+		Debug:   debug,
+		Clock:   func() int { return 0 },
+	}
+	for t := 0; t < NumGameEventTypes; t++ {
+		bus.handler.AddObserver(twodee.GameEventType(t), bus.dispatch)
+	}
+	return bus
+}
+
+func (b *EventBus) Enqueue(e twodee.GETyper) {
+	b.handler.Enqueue(e)
+}
+
+// Dispatches every queued event.
+func (b *EventBus) Poll() {
+	b.handler.Poll()
+}
+
+func (b *EventBus) Subscribe(owner interface{}, t twodee.GameEventType, callback twodee.GameEventCallback) *Observer {
+	var o = &Observer{
+		eventType: t,
+		owner:     owner,
+		callback:  callback,
+		priority:  PriorityNormal,
+		order:     b.order,
+		bus:       b,
+	}
+	b.order++
+	b.observers[t] = append(b.observers[t], o)
+	b.sort(t)
+	return o
+}
+
+func (b *EventBus) OnPlanet(owner interface{}, t twodee.GameEventType, callback func(*PlanetEvent)) *Observer {
+	return b.Subscribe(owner, t, func(e twodee.GETyper) {
+		if event, ok := e.(*PlanetEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) OnMessage(owner interface{}, callback func(*DisplayMessageEvent)) *Observer {
+	return b.Subscribe(owner, DisplayMessage, func(e twodee.GETyper) {
+		if event, ok := e.(*DisplayMessageEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) OnCheevo(owner interface{}, t twodee.GameEventType, callback func(*CheevoEvent)) *Observer {
+	return b.Subscribe(owner, t, func(e twodee.GETyper) {
+		if event, ok := e.(*CheevoEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) OnPanel(owner interface{}, callback func(*PanelEvent)) *Observer {
+	return b.Subscribe(owner, ShowPanel, func(e twodee.GETyper) {
+		if event, ok := e.(*PanelEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) OnDrop(owner interface{}, callback func(*DropPlanetEvent)) *Observer {
+	return b.Subscribe(owner, DropPlanet, func(e twodee.GETyper) {
+		if event, ok := e.(*DropPlanetEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) OnRelease(owner interface{}, callback func(*ReleasePlanetEvent)) *Observer {
+	return b.Subscribe(owner, ReleasePlanet, func(e twodee.GETyper) {
+		if event, ok := e.(*ReleasePlanetEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) OnState(owner interface{}, t twodee.GameEventType, callback func(*StateEvent)) *Observer {
+	return b.Subscribe(owner, t, func(e twodee.GETyper) {
+		if event, ok := e.(*StateEvent); ok {
+			callback(event)
+		}
+	})
+}
+
+func (b *EventBus) Unsubscribe(o *Observer) {
+	o.removed = true
+	b.compact(o.eventType)
+}
+
+// Unsubscribes every observer belonging to owner.
+func (b *EventBus) Release(owner interface{}) {
+	for t := range b.observers {
+		for _, o := range b.observers[t] {
+			if o.owner == owner {
+				o.removed = true
+			}
+		}
+		b.compact(twodee.GameEventType(t))
+	}
+}
+
+func (b *EventBus) sort(t twodee.GameEventType) {
+	sort.Stable(byDispatchOrder(b.observers[t]))
+}
+
+func (b *EventBus) compact(t twodee.GameEventType) {
+	var kept = []*Observer{}
+	for _, o := range b.observers[t] {
+		if !o.removed {
+			kept = append(kept, o)
+		}
+	}
+	b.observers[t] = kept
+}
+
+func (b *EventBus) dispatch(e twodee.GETyper) {
+	var (
+		t = e.GEType()
+		// Observers may subscribe or unsubscribe while being called, so
+		// work from a copy.
+		observers = append([]*Observer{}, b.observers[t]...)
+	)
+	if b.Debug {
+		log.Printf("tick %d: %v to %d observers", b.Clock(), eventTypeNames[t], len(observers))
+	}
+	for _, o := range observers {
+		if o.removed {
+			continue
+		}
+		if o.once {
+			b.Unsubscribe(o)
+		}
+		o.callback(e)
+	}
+}
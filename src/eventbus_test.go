@@ -0,0 +1,126 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	twodee "../libs/twodee"
+)
+
+// Subscribes an observer that appends name to calls for each GameOver event.
+func record(bus *EventBus, calls *[]string, name string) *Observer {
+	return bus.Subscribe(name, GameOver, func(e twodee.GETyper) {
+		*calls = append(*calls, name)
+	})
+}
+
+func dispatchGameOver(bus *EventBus) {
+	bus.Enqueue(twodee.NewBasicGameEvent(GameOver))
+	bus.Poll()
+}
+
+func TestEventBusOrder(t *testing.T) {
+	var (
+		bus   = NewEventBus(false)
+		calls = []string{}
+	)
+	record(bus, &calls, "normal1")
+	record(bus, &calls, "low").Prioritize(PriorityLow)
+	record(bus, &calls, "high1").Prioritize(PriorityHigh)
+	record(bus, &calls, "normal2")
+	record(bus, &calls, "high2").Prioritize(PriorityHigh)
+	dispatchGameOver(bus)
+	var want = []string{"high1", "high2", "normal1", "normal2", "low"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, want %v", calls, want)
+	}
+}
+
+func TestEventBusOnce(t *testing.T) {
+	var (
+		bus   = NewEventBus(false)
+		calls = []string{}
+	)
+	record(bus, &calls, "once").Once()
+	record(bus, &calls, "always")
+	dispatchGameOver(bus)
+	dispatchGameOver(bus)
+	var want = []string{"once", "always", "always"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, want %v", calls, want)
+	}
+}
+
+func TestEventBusUnsubscribe(t *testing.T) {
+	var (
+		bus   = NewEventBus(false)
+		calls = []string{}
+		o     = record(bus, &calls, "a")
+	)
+	record(bus, &calls, "b")
+	o.Unsubscribe()
+	dispatchGameOver(bus)
+	if want := []string{"b"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, want %v", calls, want)
+	}
+}
+
+func TestEventBusUnsubscribeDuringDispatch(t *testing.T) {
+	var (
+		bus   = NewEventBus(false)
+		calls = []string{}
+		later *Observer
+	)
+	bus.Subscribe("first", GameOver, func(e twodee.GETyper) {
+		calls = append(calls, "first")
+		later.Unsubscribe()
+	})
+	later = record(bus, &calls, "later")
+	record(bus, &calls, "last")
+	dispatchGameOver(bus)
+	dispatchGameOver(bus)
+	var want = []string{"first", "last", "first", "last"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, want %v", calls, want)
+	}
+}
+
+func TestEventBusReleaseDuringDispatch(t *testing.T) {
+	var (
+		bus   = NewEventBus(false)
+		calls = []string{}
+		owner = "owner"
+	)
+	bus.Subscribe(owner, GameOver, func(e twodee.GETyper) {
+		calls = append(calls, "releasing")
+		bus.Release(owner)
+	})
+	bus.Subscribe(owner, GameOver, func(e twodee.GETyper) {
+		calls = append(calls, "released")
+	})
+	record(bus, &calls, "other")
+	dispatchGameOver(bus)
+	dispatchGameOver(bus)
+	var want = []string{"releasing", "other", "other"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, want %v", calls, want)
+	}
+}
+
+func TestEventBusSubscribeDuringDispatch(t *testing.T) {
+	var (
+		bus   = NewEventBus(false)
+		calls = []string{}
+	)
+	bus.Subscribe("first", GameOver, func(e twodee.GETyper) {
+		calls = append(calls, "first")
+		record(bus, &calls, "added")
+	}).Once()
+	dispatchGameOver(bus)
+	dispatchGameOver(bus)
+	// An observer added during a dispatch waits for the next event.
+	var want = []string{"first", "added"}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("called %v, want %v", calls, want)
+	}
+}
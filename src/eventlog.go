@@ -0,0 +1,128 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	// The first population milestone logged; each later one is ten times
+	// bigger.
+	FirstPopulationMilestone = 1000
+)
+
+type LogEntry struct {
+	When time.Duration
+	Text string
+}
+
+// Returns the entry as it appears in the log, e.g. "2:05  VULCAN WAS BORN".
+func (e LogEntry) String() string {
+	var s = int64(e.When.Seconds())
+	return fmt.Sprintf("%d:%02d  %v", s/60, s%60, e.Text)
+}
+
+// EventLog keeps a timestamped record of everything that happened in a game,
+// so that messages shown in a hectic moment are not lost.
+type EventLog struct {
+	Entries       []LogEntry
+	sim           *Simulation
+	events        *EventBus
+	clock         time.Duration
+	nextMilestone int
+	// Planets whose death is already logged, as death events repeat until
+	// the planet is removed.
+	dead map[int]bool
+}
+
+func NewEventLog(events *EventBus, sim *Simulation) (eventLog *EventLog) {
+	eventLog = &EventLog{
+		Entries:       []LogEntry{},
+		sim:           sim,
+		events:        events,
+		nextMilestone: FirstPopulationMilestone,
+		dead:          map[int]bool{},
+	}
+	events.OnMessage(eventLog, eventLog.OnDisplayMessage)
+	events.OnPlanet(eventLog, PlanetBorn, eventLog.OnPlanetEvent)
+	events.OnPlanet(eventLog, PlanetFireDeath, eventLog.OnPlanetEvent)
+	events.OnPlanet(eventLog, PlanetCollision, eventLog.OnPlanetEvent)
+	events.OnPlanet(eventLog, PlanetEscaped, eventLog.OnPlanetEvent)
+	events.OnCheevo(eventLog, CheevoSuccess, eventLog.OnCheevo)
+	events.OnCheevo(eventLog, CheevoFailure, eventLog.OnCheevo)
+	return
+}
+
+func (l *EventLog) Delete() {
+	l.events.Release(l)
+}
+
+// Advances the log's clock and notes any population milestones reached.
+func (l *EventLog) Update(elapsed time.Duration) {
+	l.clock += elapsed
+	for l.sim.GetPopulation() >= l.nextMilestone {
+		l.Add(fmt.Sprintf("POPULATION PASSED %d", l.nextMilestone))
+		l.nextMilestone *= 10
+	}
+}
+
+// Adds an entry stamped with the log's clock.
+func (l *EventLog) Add(text string) {
+	l.Entries = append(l.Entries, LogEntry{l.clock, text})
+}
+
+func (l *EventLog) OnDisplayMessage(event *DisplayMessageEvent) {
+	// Empty messages just clear the screen.
+	if event.Message != "" {
+		l.Add(event.Message)
+	}
+}
+
+func (l *EventLog) OnPlanetEvent(event *PlanetEvent) {
+	var name = event.Planet.Name
+	if event.GEType() != PlanetBorn {
+		if l.dead[event.PlanetId] {
+			return
+		}
+		l.dead[event.PlanetId] = true
+	}
+	switch event.GEType() {
+	case PlanetBorn:
+		l.Add(fmt.Sprintf("%v WAS BORN", name))
+	case PlanetFireDeath:
+		l.Add(fmt.Sprintf("%v FELL INTO THE SUN (%d LOST)", name, event.Population))
+	case PlanetCollision:
+		l.Add(fmt.Sprintf("%v WAS DESTROYED IN A COLLISION (%d LOST)", name, event.Population))
+	case PlanetEscaped:
+		l.Add(fmt.Sprintf("%v WAS LOST TO THE VOID", name))
+	}
+}
+
+func (l *EventLog) OnCheevo(event *CheevoEvent) {
+	if event.GEType() == CheevoSuccess {
+		l.Add(fmt.Sprintf("CHEEVO EARNED: %v", event.Label))
+	} else {
+		l.Add(fmt.Sprintf("CHEEVO FAILED: %v", event.Label))
+	}
+}
+
+// Writes the whole log to a text file, one entry per line.
+func (l *EventLog) WriteFile(path string) (err error) {
+	var f *os.File
+	if f, err = os.Create(path); err != nil {
+		return
+	}
+	defer f.Close()
+	var w = bufio.NewWriter(f)
+	for _, entry := range l.Entries {
+		if _, err = fmt.Fprintln(w, entry.String()); err != nil {
+			return
+		}
+	}
+	return w.Flush()
+}
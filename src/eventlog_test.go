@@ -0,0 +1,34 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"testing"
+
+	twodee "../libs/twodee"
+)
+
+func TestEventLogOneEntryPerDeath(t *testing.T) {
+	var (
+		events   = NewEventBus(false)
+		eventLog = NewEventLog(events, nil)
+		planet   = &PlanetaryBody{Name: "HOTH", Id: 1}
+	)
+	// The simulation repeats a death event on every tick of the death.
+	for i := 0; i < 3; i++ {
+		events.Enqueue(&PlanetEvent{
+			BasicGameEvent: *twodee.NewBasicGameEvent(PlanetCollision),
+			Planet:         planet,
+			PlanetId:       planet.Id,
+			Population:     100,
+		})
+	}
+	events.Poll()
+	if len(eventLog.Entries) != 1 {
+		t.Fatalf("got entries %v, want one", eventLog.Entries)
+	}
+	if want := "HOTH WAS DESTROYED IN A COLLISION (100 LOST)"; eventLog.Entries[0].Text != want {
+		t.Errorf("got %q, want %q", eventLog.Entries[0].Text, want)
+	}
+}
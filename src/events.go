@@ -15,11 +15,17 @@ const (
 	ResumeMusic
 	GameOver
 	DisplayMessage
-	MenuOpen
-	MenuClose
+	StateEnter
+	StateExit
 	MenuClick
 	MenuSel
 	ShowEndScreen
+	CheevoSuccess
+	CheevoFailure
+	ShowPanel
+	HidePanel
+	PlanetEscaped
+	PlanetBorn
 	sentinel
 )
 
@@ -27,6 +33,30 @@ const (
 	NumGameEventTypes = int(sentinel)
 )
 
+var eventTypeNames = [NumGameEventTypes]string{
+	"GameIsClosing",
+	"PlayBackgroundMusic",
+	"DropPlanet",
+	"PlanetFireDeath",
+	"PlanetCollision",
+	"ReleasePlanet",
+	"PauseMusic",
+	"ResumeMusic",
+	"GameOver",
+	"DisplayMessage",
+	"StateEnter",
+	"StateExit",
+	"MenuClick",
+	"MenuSel",
+	"ShowEndScreen",
+	"CheevoSuccess",
+	"CheevoFailure",
+	"ShowPanel",
+	"HidePanel",
+	"PlanetEscaped",
+	"PlanetBorn",
+}
+
 type DisplayMessageEvent struct {
 	twodee.BasicGameEvent
 	Positioned bool
@@ -36,21 +66,52 @@ type DisplayMessageEvent struct {
 
 type DropPlanetEvent struct {
 	twodee.BasicGameEvent
-	X float32
-	Y float32
+	X   float32
+	Y   float32
+	Bot bool // Sent by a bot rather than the mouse.
 }
 
+// PlanetEvent carries the planet along with its state when the event was
+// enqueued, as the planet may have moved on by the time it is dispatched.
 type PlanetEvent struct {
 	twodee.BasicGameEvent
-	Planet *PlanetaryBody
+	Planet     *PlanetaryBody
+	PlanetId   int
+	Tick       int
+	Population int
+	Pos        twodee.Point
+	Velocity   twodee.Point
 }
 
 type ReleasePlanetEvent DropPlanetEvent
 
-func NewPlanetEvent(eventType twodee.GameEventType, planet *PlanetaryBody) (e *PlanetEvent) {
+type CheevoEvent struct {
+	twodee.BasicGameEvent
+	Label string
+}
+
+// StateEvent is sent when leaving one game state for another.
+type StateEvent struct {
+	twodee.BasicGameEvent
+	From GameState
+	To   GameState
+}
+
+type PanelEvent struct {
+	twodee.BasicGameEvent
+	Title string
+	Lines []string
+}
+
+func NewPlanetEvent(eventType twodee.GameEventType, planet *PlanetaryBody, tick int) (e *PlanetEvent) {
 	return &PlanetEvent{
 		*twodee.NewBasicGameEvent(eventType),
 		planet,
+		planet.Id,
+		tick,
+		planet.GetPopulation(),
+		planet.Pos(),
+		planet.Velocity,
 	}
 }
 
@@ -59,6 +120,7 @@ func NewDropPlanetEvent(x, y float32) (e *DropPlanetEvent) {
 		*twodee.NewBasicGameEvent(DropPlanet),
 		x,
 		y,
+		false,
 	}
 	return
 }
@@ -68,6 +130,7 @@ func NewReleasePlanetEvent(x, y float32) (e *ReleasePlanetEvent) {
 		*twodee.NewBasicGameEvent(ReleasePlanet),
 		x,
 		y,
+		false,
 	}
 	return
 }
@@ -89,3 +152,26 @@ func NewMessageEvent(message string) (e *DisplayMessageEvent) {
 		message,
 	}
 }
+
+func NewCheevoEvent(eventType twodee.GameEventType, label string) (e *CheevoEvent) {
+	return &CheevoEvent{
+		*twodee.NewBasicGameEvent(eventType),
+		label,
+	}
+}
+
+func NewPanelEvent(title string, lines []string) (e *PanelEvent) {
+	return &PanelEvent{
+		*twodee.NewBasicGameEvent(ShowPanel),
+		title,
+		lines,
+	}
+}
+
+func NewStateEvent(eventType twodee.GameEventType, from, to GameState) (e *StateEvent) {
+	return &StateEvent{
+		*twodee.NewBasicGameEvent(eventType),
+		from,
+		to,
+	}
+}
@@ -1,50 +1,87 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import (
+	"fmt"
+	"image/color"
+	"log"
 	"math"
+	"strings"
 	"time"
 
 	twodee "../libs/twodee"
 )
 
 const (
-	magicVelocityScalingFactor = 1e-3
-	// Starting time is 5minutes.
-	startDur = time.Duration(5) * time.Minute
+	// Index into timeScales for normal speed.
+	normalTimeScale = 2
+)
+
+var (
+	timeScales     = []float64{0.25, 0.5, 1, 2, 4, 8}
+	selectionColor = color.RGBA{255, 240, 120, 200}
 )
 
 type GameLayer struct {
-	BatchRenderer         *twodee.BatchRenderer
-	TileRenderer          *twodee.TileRenderer
-	GlowRenderer          *GlowRenderer
-	Bounds                twodee.Rectangle
-	App                   *Application
-	Sim                   *Simulation
-	Starmap               *twodee.Batch
-	Cheevos               *Cheevos
-	MouseX                float32
-	MouseY                float32
-	DropPlanetListener    int
-	ReleasePlanetListener int
-	openMenuListener      int
-	closeMenuListener     int
-	gameOverListener      int
-	DurLeft               time.Duration
-	phantomPlanet         *PlanetaryBody
-	count                 int64
-	paused                bool
+	BatchRenderer *twodee.BatchRenderer
+	TileRenderer  *twodee.TileRenderer
+	GlowRenderer  *GlowRenderer
+	ShapeRenderer *ShapeRenderer
+	Bounds        twodee.Rectangle
+	FieldBounds   twodee.Rectangle
+	Camera        *Camera
+	Follow        FollowMode
+	SelectedId    int
+	Tools         *Toolbox
+	Trails        map[int]*Trail
+	ShowOrbits    bool
+	Gravity       *GravityField
+	ShowGravity   bool
+	Renaming      *TextInput
+	App           *Application
+	Sim           *Simulation
+	Starmap       *twodee.Batch
+	Cheevos       *Cheevos
+	Score         *Score
+	Log           *EventLog
+	Rewind        *RewindBuffer
+	MouseX        float32
+	MouseY        float32
+	mouseScreen   twodee.Point
+	viewBounds    twodee.Rectangle
+	dragging      bool
+	panDir        twodee.Point
+	panKeys       map[InputAction]bool
+	DurLeft       time.Duration
+	Launcher      *Launcher
+	Bot           Player // Launches planets alongside the player, if set.
+	Demo          bool   // Set while a bot plays a game behind the title.
+	botLauncher   *Launcher
+	// Demos run on their own bus, so that the trace, log, HUD and audio
+	// never hear of them.
+	demoEvents    *EventBus
+	count         int64
+	stepOnce      bool
+	timeScale     int
+	rewinding     bool
+	rewindElapsed time.Duration
 }
 
 func NewGameLayer(app *Application) (layer *GameLayer, err error) {
-	var bounds = twodee.Rect(-48, -36, 48, 36)
+	var (
+		bounds = defaultViewBounds
+		field  = FieldBounds(app.FieldScale)
+	)
 	layer = &GameLayer{
-		App:           app,
-		Bounds:        bounds,
-		Sim:           NewSimulation(bounds, app.GameEventHandler),
-		DurLeft:       startDur,
-		phantomPlanet: nil,
-		count:         0,
-		paused:        false,
+		App:         app,
+		Bounds:      bounds,
+		FieldBounds: field,
+		panKeys:     map[InputAction]bool{},
+		ShowOrbits:  false,
+		Gravity:     NewGravityField(),
+		ShowGravity: false,
 	}
 	if layer.BatchRenderer, err = twodee.NewBatchRenderer(layer.Bounds, app.WinBounds); err != nil {
 		return
@@ -62,15 +99,19 @@ func NewGameLayer(app *Application) (layer *GameLayer, err error) {
 	if layer.GlowRenderer, err = NewGlowRenderer(192, 128, 6, 0.3, 1.0); err != nil {
 		return
 	}
+	if layer.ShapeRenderer, err = NewShapeRenderer(layer.Bounds); err != nil {
+		return
+	}
 	if layer.Starmap, err = LoadMap("assets/starmap.tmx"); err != nil {
 		return
 	}
-	layer.Cheevos = NewCheevos(layer.App.GameEventHandler, layer.Sim)
-	layer.DropPlanetListener = layer.App.GameEventHandler.AddObserver(DropPlanet, layer.OnDropPlanet)
-	layer.ReleasePlanetListener = layer.App.GameEventHandler.AddObserver(ReleasePlanet, layer.OnReleasePlanet)
-	layer.openMenuListener = layer.App.GameEventHandler.AddObserver(MenuOpen, layer.OnMenuToggle)
-	layer.closeMenuListener = layer.App.GameEventHandler.AddObserver(MenuClose, layer.OnMenuToggle)
-	layer.gameOverListener = layer.App.GameEventHandler.AddObserver(GameOver, layer.OnGameOver)
+	layer.demoEvents = NewEventBus(false)
+	layer.startGame(app.Seed, app.Events)
+	// Record the game before anything shows the end screen.
+	layer.App.Events.Subscribe(layer, GameOver, layer.OnGameOver).Prioritize(PriorityHigh)
+	layer.App.Events.Clock = func() int {
+		return layer.Sim.Tick
+	}
 	return
 }
 
@@ -84,17 +125,191 @@ func (l *GameLayer) Delete() {
 	if l.GlowRenderer != nil {
 		l.GlowRenderer.Delete()
 	}
+	if l.ShapeRenderer != nil {
+		l.ShapeRenderer.Delete()
+	}
 	if l.Starmap != nil {
 		l.Starmap.Delete()
 	}
+	l.endGame()
+	l.App.Events.Release(l)
+}
+
+// Throws away the current game, if any, and sets up a fresh one whose
+// events go to events.
+func (l *GameLayer) startGame(seed int64, events *EventBus) {
+	l.endGame()
+	l.Sim = NewSimulation(l.FieldBounds, events, seed)
+	l.Cheevos = NewCheevos(events, l.Sim)
+	if l.App.Profile.IsExperienced() {
+		l.Cheevos.SkipTutorial()
+	}
+	l.Score = NewScore(events, l.Sim)
+	l.Log = NewEventLog(events, l.Sim)
+	l.Launcher = NewLauncher(l.Sim)
+	l.Launcher.Listen(events)
+	l.botLauncher = NewLauncher(l.Sim)
+	l.botLauncher.ListenForBot(events)
+	l.Rewind = NewRewindBuffer()
+	l.Tools = NewToolbox()
+	l.Trails = map[int]*Trail{}
+	l.Camera = NewCamera(l.Bounds, l.FieldBounds)
+	l.viewBounds = l.Bounds
+	l.Follow = FollowNone
+	l.SelectedId = 0
+	l.Renaming = nil
+	l.Bot = nil
+	l.Demo = false
+	l.DurLeft = startDur
+	l.count = 0
+	l.stepOnce = false
+	l.timeScale = normalTimeScale
+	l.rewinding = false
+}
+
+// Unsubscribes everything belonging to the current game.
+func (l *GameLayer) endGame() {
 	if l.Cheevos != nil {
 		l.Cheevos.Delete()
 	}
-	l.App.GameEventHandler.RemoveObserver(DropPlanet, l.DropPlanetListener)
-	l.App.GameEventHandler.RemoveObserver(ReleasePlanet, l.ReleasePlanetListener)
-	l.App.GameEventHandler.RemoveObserver(MenuOpen, l.openMenuListener)
-	l.App.GameEventHandler.RemoveObserver(MenuClose, l.closeMenuListener)
-	l.App.GameEventHandler.RemoveObserver(GameOver, l.gameOverListener)
+	if l.Score != nil {
+		l.Score.Delete()
+	}
+	if l.Log != nil {
+		l.Log.Delete()
+	}
+	if l.Launcher != nil {
+		l.Launcher.Delete()
+	}
+	if l.botLauncher != nil {
+		l.botLauncher.Delete()
+	}
+}
+
+// Starts a fresh game for the player, from the title or the end screen. The
+// bot chosen on the command line, if any, plays alongside.
+func (l *GameLayer) NewGame(seed int64) (err error) {
+	if err = l.App.State.Goto(StatePlaying); err != nil {
+		return
+	}
+	l.startGame(seed, l.App.Events)
+	l.App.Seed = seed
+	if l.App.Bot != "" {
+		l.Bot, err = NewBot(l.App.Bot, seed)
+	}
+	return
+}
+
+// Starts a game played by bot, to show off behind the title. Its events stay
+// within the game layer, so nothing that happens in it is traced, logged,
+// heard or counted towards the profile.
+func (l *GameLayer) StartDemo(bot Player, seed int64) {
+	l.startGame(seed, l.demoEvents)
+	l.Bot = bot
+	l.Demo = true
+}
+
+// Keeps the camera centred on whatever it is following.
+func (l *GameLayer) updateFollow() {
+	var selected = l.Selected()
+	if selected == nil {
+		l.SelectedId = 0
+		if l.Follow == FollowPlanet {
+			l.Follow = FollowNone
+		}
+	}
+	switch l.Follow {
+	case FollowPlanet:
+		l.Camera.LookAt(selected.Pos())
+	case FollowSun:
+		l.Camera.LookAt(l.Sim.Sun.Pos())
+	case FollowBarycentre:
+		l.Camera.LookAt(l.Sim.Barycentre())
+	}
+}
+
+// Returns the selected planet, or nil if none is selected or it has died.
+func (l *GameLayer) Selected() *PlanetaryBody {
+	if p := l.Sim.Body(l.SelectedId); p != nil && p.IsAlive() {
+		return p
+	}
+	return nil
+}
+
+func (l *GameLayer) SelectPlanet(p *PlanetaryBody) {
+	l.SelectedId = p.Id
+	l.Follow = FollowPlanet
+	l.Renaming = nil
+}
+
+func (l *GameLayer) UseTool(index int) {
+	if err := l.Tools.Use(index, l, l.Selected()); err != nil {
+		l.App.Events.Enqueue(NewMessageEvent(err.Error()))
+	}
+}
+
+func (l *GameLayer) StartRename() {
+	l.Renaming = NewTextInput(maxNameLength)
+}
+
+// Sends key presses to the rename box while it is open. Confirming an
+// empty name, or pressing the menu key, leaves the planet as it was.
+func (l *GameLayer) handleRenameKey(event *twodee.KeyEvent) {
+	if event.Type != twodee.Press {
+		return
+	}
+	switch {
+	case l.App.Keys.Is(ActionConfirm, event.Code):
+		if name := strings.TrimSpace(l.Renaming.Value); name != "" && l.Selected() != nil {
+			l.Selected().Name = name
+		}
+		l.Renaming = nil
+	case l.App.Keys.Is(ActionMenu, event.Code):
+		l.Renaming = nil
+	default:
+		l.Renaming.HandleKey(event.Code)
+	}
+}
+
+// Cycles the camera between the sun, the barycentre and free movement.
+func (l *GameLayer) CycleFollow() {
+	switch l.Follow {
+	case FollowSun:
+		l.Follow = FollowBarycentre
+	case FollowBarycentre:
+		l.Follow = FollowNone
+	default:
+		l.Follow = FollowSun
+	}
+}
+
+// Points the renderers at whatever the camera is looking at.
+func (l *GameLayer) applyCamera() {
+	var view = l.Camera.Bounds()
+	if view == l.viewBounds {
+		return
+	}
+	l.viewBounds = view
+	l.TileRenderer.SetWorldBounds(view)
+	l.BatchRenderer.SetWorldBounds(view)
+	l.ShapeRenderer.SetWorldBounds(view)
+	l.MouseX, l.MouseY = l.TileRenderer.ScreenToWorldCoords(l.mouseScreen.X, l.mouseScreen.Y)
+}
+
+// The starmap covers the default view, so repeat it across whatever is
+// visible.
+func (l *GameLayer) drawStarmap() {
+	var (
+		w  = l.Bounds.Max.X - l.Bounds.Min.X
+		h  = l.Bounds.Max.Y - l.Bounds.Min.Y
+		x0 = l.Bounds.Min.X + w*float32(math.Floor(float64((l.viewBounds.Min.X-l.Bounds.Min.X)/w)))
+		y0 = l.Bounds.Min.Y + h*float32(math.Floor(float64((l.viewBounds.Min.Y-l.Bounds.Min.Y)/h)))
+	)
+	for x := x0; x < l.viewBounds.Max.X; x += w {
+		for y := y0; y < l.viewBounds.Max.Y; y += h {
+			l.BatchRenderer.Draw(l.Starmap, x, y, 0)
+		}
+	}
 }
 
 func (l *GameLayer) Render() {
@@ -102,6 +317,8 @@ func (l *GameLayer) Render() {
 		pos     twodee.Point
 		radians float64
 	)
+	l.updateFollow()
+	l.applyCamera()
 	l.count = (l.count + 2) % 100000000
 	radians = 0.0174532925 * float64(l.count)
 	var glow = math.Sin(radians) * 0.1
@@ -121,7 +338,7 @@ func (l *GameLayer) Render() {
 	*/
 
 	l.BatchRenderer.Bind()
-	l.BatchRenderer.Draw(l.Starmap, l.Bounds.Min.X, l.Bounds.Min.Y, 0)
+	l.drawStarmap()
 	l.BatchRenderer.Unbind()
 
 	l.TileRenderer.Bind()
@@ -142,101 +359,394 @@ func (l *GameLayer) Render() {
 	l.TileRenderer.Unbind()
 
 	l.BatchRenderer.Bind()
-	l.BatchRenderer.Draw(l.Starmap, l.Bounds.Min.X, l.Bounds.Min.Y, 0)
+	l.drawStarmap()
 	l.BatchRenderer.Unbind()
 
+	l.ShapeRenderer.Bind()
+	if l.ShowGravity {
+		l.Gravity.Update(l.Sim, l.viewBounds)
+		l.ShapeRenderer.DrawTriangles(l.Gravity.Vertices())
+	}
+	l.drawLifeZone()
+	l.drawOrbits()
+	if selected := l.Selected(); selected != nil {
+		l.ShapeRenderer.DrawLineLoop(CircleVertices(selected.Pos(), selected.Radius*1.4, 32, selectionColor))
+	}
+	l.ShapeRenderer.Unbind()
+
 	l.TileRenderer.Bind()
 	l.TileRenderer.Draw(l.Sim.Sun.Frame(), pos.X, pos.Y, float32(radians), false, false)
 	for _, p := range l.Sim.Planets {
 		pos = p.Pos()
 		l.TileRenderer.DrawScaled(p.Frame(), pos.X, pos.Y, p.Rotation, p.Scale, false, false)
 	}
-	if l.phantomPlanet != nil {
-		p := l.phantomPlanet
+	if l.Launcher.Phantom != nil {
+		p := l.Launcher.Phantom
 		pos = p.Pos()
 		l.TileRenderer.DrawScaled(p.Frame(), pos.X, pos.Y, 0, p.Scale, false, false)
 	}
 	l.TileRenderer.Unbind()
 
 	l.GlowRenderer.Draw()
+
+	l.ShapeRenderer.Bind()
+	l.drawIndicators()
+	l.ShapeRenderer.Unbind()
 	return
 }
 
+// Draws each planet's trail and, if enabled, its predicted orbit.
+func (l *GameLayer) drawOrbits() {
+	for _, p := range l.Sim.Planets {
+		if !p.IsAlive() {
+			continue
+		}
+		if trail, ok := l.Trails[p.Id]; ok {
+			l.ShapeRenderer.DrawLineStrip(trail.Vertices(p.Pos()))
+		}
+		if l.ShowOrbits {
+			var orbit = PredictOrbit(p, l.Sim.Sun)
+			l.ShapeRenderer.DrawLineLoop(orbit.Vertices(orbit.Color(p, l.Sim.Sun)))
+		}
+	}
+}
+
+func (l *GameLayer) updateTrails(elapsed time.Duration) {
+	var alive = map[int]bool{}
+	for _, p := range l.Sim.Planets {
+		if !p.IsAlive() {
+			continue
+		}
+		alive[p.Id] = true
+		if _, ok := l.Trails[p.Id]; !ok {
+			l.Trails[p.Id] = NewTrail()
+		}
+		l.Trails[p.Id].Update(elapsed, p.Pos())
+	}
+	for id := range l.Trails {
+		if !alive[id] {
+			delete(l.Trails, id)
+		}
+	}
+}
+
+// Returns whether the simulation runs in the current state. The title
+// shows a frozen game until a demo starts.
+func (l *GameLayer) isRunning() bool {
+	switch l.App.State.Current() {
+	case StatePlaying, StatePaused:
+		return true
+	case StateTitle:
+		return l.Demo
+	}
+	return false
+}
+
 func (l *GameLayer) Update(elapsed time.Duration) {
-	if l.paused {
+	if !l.isRunning() {
+		return
+	}
+	if l.panDir.X != 0 || l.panDir.Y != 0 {
+		var dist = PanSpeed * float32(elapsed.Seconds()) / l.Camera.Zoom
+		l.Camera.Pan(l.panDir.X*dist, l.panDir.Y*dist)
+	}
+	if l.rewinding {
+		l.updateRewind(elapsed)
 		return
 	}
+	if l.App.State.Is(StatePaused) {
+		if l.stepOnce {
+			l.stepOnce = false
+			l.tick(twodee.Step60Hz)
+		}
+		return
+	}
+	// Run faster speeds as several ticks so the n-body integration stays
+	// as stable as it is at normal speed.
+	var scaled = time.Duration(float64(elapsed) * timeScales[l.timeScale])
+	for scaled > 0 && l.DurLeft > 0 {
+		var step = scaled
+		if step > elapsed {
+			step = elapsed
+		}
+		l.tick(step)
+		scaled -= step
+	}
+	if l.Demo {
+		l.demoEvents.Poll()
+	}
+}
+
+func (l *GameLayer) tick(elapsed time.Duration) {
+	if l.Bot != nil {
+		ApplyActions(l.Sim.Events, l.Bot.Play(elapsed, NewSimView(l.Sim)))
+	}
 	l.Sim.Update(elapsed)
-	l.Cheevos.Update(elapsed)
+	if !l.Demo {
+		// Cheevo messages would only clutter the title.
+		l.Cheevos.Update(elapsed)
+	}
+	l.Score.Update(elapsed)
+	l.Log.Update(elapsed)
+	l.Tools.Update(elapsed, l.Sim)
+	l.updateTrails(elapsed)
+	l.Rewind.Update(elapsed, l.Snapshot)
 	l.DurLeft -= elapsed
-	if l.DurLeft <= 0 {
+	if l.DurLeft <= 0 && l.Demo {
+		l.StartDemo(l.Bot, l.Sim.Rand.Int63())
+	} else if l.DurLeft <= 0 {
 		l.DurLeft = time.Duration(0)
-		l.App.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(GameOver))
+		l.App.EndGame()
+	}
+}
+
+func (l *GameLayer) Snapshot() *GameSnapshot {
+	return &GameSnapshot{
+		sim:     l.Sim.Snapshot(),
+		cheevos: l.Cheevos.Snapshot(),
+		score:   l.Score.Snapshot(),
+	}
+}
+
+// Rolls the game back to a snapshot. The clock keeps running; rewinding
+// cannot buy more time before the supernova.
+func (l *GameLayer) Restore(snap *GameSnapshot) {
+	l.Sim.Restore(snap.sim)
+	l.Cheevos.Restore(snap.cheevos)
+	l.Score.Restore(snap.score)
+	l.Tools.sacrificing = []int{}
+	l.Trails = map[int]*Trail{}
+}
+
+func (l *GameLayer) StartRewind() {
+	if l.App.Ranked {
+		l.App.Events.Enqueue(NewMessageEvent("NO REWINDS IN RANKED GAMES"))
+		return
+	}
+	if l.rewinding || l.Rewind.Len() == 0 {
+		return
+	}
+	l.rewinding = true
+	l.rewindElapsed = RewindScrubRate
+	l.Launcher.Cancel()
+	l.botLauncher.Cancel()
+	l.Score.Rewinds++
+}
+
+func (l *GameLayer) StopRewind() {
+	l.rewinding = false
+}
+
+// Steps back through snapshots for as long as rewind is held.
+func (l *GameLayer) updateRewind(elapsed time.Duration) {
+	var snap *GameSnapshot
+	l.rewindElapsed += elapsed
+	for l.rewindElapsed >= RewindScrubRate {
+		l.rewindElapsed -= RewindScrubRate
+		if snap = l.Rewind.Pop(); snap == nil {
+			break
+		}
+		l.Restore(snap)
+	}
+}
+
+func (l *GameLayer) IsRewinding() bool {
+	return l.rewinding
+}
+
+func (l *GameLayer) TogglePause() {
+	if l.App.State.Is(StatePaused) {
+		l.App.State.Goto(StatePlaying)
+	} else {
+		l.App.State.Goto(StatePaused)
+	}
+	l.stepOnce = false
+}
+
+// Advances the simulation by a single tick while paused.
+func (l *GameLayer) Step() {
+	if l.App.State.Is(StatePaused) {
+		l.stepOnce = true
+	}
+}
+
+func (l *GameLayer) SpeedUp() {
+	if l.timeScale < len(timeScales)-1 {
+		l.timeScale++
+	}
+}
+
+func (l *GameLayer) SlowDown() {
+	if l.timeScale > 0 {
+		l.timeScale--
 	}
 }
 
+func (l *GameLayer) TimeScale() float64 {
+	return timeScales[l.timeScale]
+}
+
+// Returns whether the player paused the game, even if the menu has since
+// been opened over it.
+func (l *GameLayer) IsUserPaused() bool {
+	return l.App.State.Base() == StatePaused
+}
+
 func (l *GameLayer) Reset() (err error) {
 	return
 }
 
 func (l *GameLayer) HandleEvent(evt twodee.Event) bool {
+	// Only a game in progress takes input; keys passed on by the menu or
+	// the end screen must not pause or resume it.
+	if !l.App.State.Is(StatePlaying) && !l.App.State.Is(StatePaused) {
+		return true
+	}
 	switch event := evt.(type) {
 	case *twodee.KeyEvent:
+		if l.Renaming != nil {
+			l.handleRenameKey(event)
+			return false
+		}
+		var action, ok = l.App.Keys.Action(event.Code, ContextPlay)
+		if !ok {
+			break
+		}
+		if action == ActionRewind {
+			switch event.Type {
+			case twodee.Press:
+				l.StartRewind()
+			case twodee.Release:
+				l.StopRewind()
+			}
+			return false
+		}
+		if l.handlePanKey(action, event.Type) {
+			return false
+		}
 		if event.Type != twodee.Press {
 			break
 		}
-		switch event.Code {
-		case twodee.KeyEscape:
-			l.App.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(GameIsClosing))
+		switch action {
+		case ActionFollow:
+			l.CycleFollow()
+			return false
+		case ActionOrbits:
+			l.ShowOrbits = !l.ShowOrbits
+			return false
+		case ActionGravity:
+			l.ShowGravity = !l.ShowGravity
+			return false
+		case ActionTool1, ActionTool2, ActionTool3:
+			l.UseTool(int(action - ActionTool1))
+			return false
+		case ActionZoomIn:
+			l.Camera.ZoomAt(ZoomStep, l.Camera.Center)
+			return false
+		case ActionZoomOut:
+			l.Camera.ZoomAt(1/ZoomStep, l.Camera.Center)
+			return false
+		case ActionPause:
+			l.TogglePause()
+			return false
+		case ActionStep:
+			l.Step()
+			return false
+		case ActionSpeedUp:
+			l.SpeedUp()
+			return false
+		case ActionSlowDown:
+			l.SlowDown()
 			return false
 		}
 	case *twodee.MouseButtonEvent:
+		if event.Button == twodee.MouseButtonRight {
+			l.dragging = event.Type == twodee.Press
+			if l.dragging {
+				l.Follow = FollowNone
+			}
+			break
+		}
 		switch event.Type {
 		case twodee.Press:
-			l.App.GameEventHandler.Enqueue(NewDropPlanetEvent(l.MouseX, l.MouseY))
+			if p := l.Sim.PlanetAt(twodee.Pt(l.MouseX, l.MouseY)); p != nil {
+				l.SelectPlanet(p)
+				break
+			}
+			l.App.Events.Enqueue(NewDropPlanetEvent(l.MouseX, l.MouseY))
 		case twodee.Release:
-			l.App.GameEventHandler.Enqueue(NewReleasePlanetEvent(l.MouseX, l.MouseY))
+			l.App.Events.Enqueue(NewReleasePlanetEvent(l.MouseX, l.MouseY))
 		default:
 			break
 		}
 	case *twodee.MouseMoveEvent:
-		l.MouseX, l.MouseY = l.TileRenderer.ScreenToWorldCoords(event.X, event.Y)
+		var x, y = l.TileRenderer.ScreenToWorldCoords(event.X, event.Y)
+		if l.dragging {
+			// Drag the world along with the cursor.
+			l.Camera.Pan(l.MouseX-x, l.MouseY-y)
+			l.applyCamera()
+			x, y = l.TileRenderer.ScreenToWorldCoords(event.X, event.Y)
+		}
+		l.mouseScreen = twodee.Pt(event.X, event.Y)
+		l.MouseX, l.MouseY = x, y
+	case *MouseScrollEvent:
+		if event.Y > 0 {
+			l.Camera.ZoomAt(ZoomStep, twodee.Pt(l.MouseX, l.MouseY))
+		} else if event.Y < 0 {
+			l.Camera.ZoomAt(1/ZoomStep, twodee.Pt(l.MouseX, l.MouseY))
+		}
+		return false
 	}
 	return true
 }
 
-func (l *GameLayer) OnDropPlanet(evt twodee.GETyper) {
-	switch event := evt.(type) {
-	case *DropPlanetEvent:
-		l.phantomPlanet = NewPlanet(event.X, event.Y)
-		l.phantomPlanet.SetState(Phantom)
+// Tracks which pan keys are held down. Returns true if the action was one.
+func (l *GameLayer) handlePanKey(action InputAction, t twodee.Action) bool {
+	switch action {
+	case ActionPanLeft, ActionPanRight, ActionPanDown, ActionPanUp:
+	default:
+		return false
 	}
-}
-
-func (l *GameLayer) OnReleasePlanet(evt twodee.GETyper) {
-	switch event := evt.(type) {
-	case *ReleasePlanetEvent:
-		// do something.
-		if l.phantomPlanet != nil {
-			p := l.phantomPlanet.Pos()
-			relVector := twodee.Pt(event.X-p.X, event.Y-p.Y)
-			// Since the vector's magnitude is still too big, we
-			// need to scale it down by some magic factor.
-			relVector = relVector.Scale(magicVelocityScalingFactor)
-			l.phantomPlanet.Velocity = relVector
-			l.phantomPlanet.RemState(Phantom)
-			l.Sim.AddPlanet(l.phantomPlanet)
-			l.phantomPlanet = nil
-		}
+	switch t {
+	case twodee.Press:
+		l.panKeys[action] = true
+		l.Follow = FollowNone
+	case twodee.Release:
+		l.panKeys[action] = false
+	}
+	l.panDir = twodee.Pt(0, 0)
+	if l.panKeys[ActionPanLeft] {
+		l.panDir.X -= 1
 	}
+	if l.panKeys[ActionPanRight] {
+		l.panDir.X += 1
+	}
+	if l.panKeys[ActionPanDown] {
+		l.panDir.Y -= 1
+	}
+	if l.panKeys[ActionPanUp] {
+		l.panDir.Y += 1
+	}
+	return true
 }
 
-func (l *GameLayer) OnMenuToggle(evt twodee.GETyper) {
-	l.paused = !l.paused
+func (l *GameLayer) OnGameOver(evt twodee.GETyper) {
+	if err := l.App.Profile.RecordGame(l.Sim, l.Cheevos.Passed); err != nil {
+		log.Printf("Could not save profile: %v", err)
+	}
+	if err := l.saveLog(); err != nil {
+		log.Printf("Could not save event log: %v", err)
+	}
 }
 
-func (l *GameLayer) OnGameOver(evt twodee.GETyper) {
-	l.paused = true
+// Writes the event log next to the profile, named after the time the game
+// ended.
+func (l *GameLayer) saveLog() (err error) {
+	var path string
+	if path, err = userDataPath(fmt.Sprintf("game-%v.log", time.Now().Format("20060102-150405"))); err != nil {
+		return
+	}
+	return l.Log.WriteFile(path)
 }
 
 func (l *GameLayer) WorldToScreenCoords(pt twodee.Point) twodee.Point {
@@ -1,3 +1,6 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import (
@@ -0,0 +1,107 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+	"math"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// Grid cells across the width of the view; rows follow the aspect ratio.
+	GravityFieldColumns = 48
+	// Frames between resampling the field.
+	GravityFieldInterval = 6
+	// Potentials are coloured on a log scale between these powers of ten.
+	gravityFieldLogMin = -5.0
+	gravityFieldLogMax = -2.5
+)
+
+var (
+	shallowWellColor = color.RGBA{20, 40, 160, 30}
+	deepWellColor    = color.RGBA{255, 140, 40, 140}
+)
+
+// GravityField is a colour map of how deep the gravity well is at each
+// point of the view.
+type GravityField struct {
+	vertices []ShapeVertex
+	view     twodee.Rectangle
+	frames   int
+}
+
+func NewGravityField() *GravityField {
+	return &GravityField{
+		vertices: []ShapeVertex{},
+	}
+}
+
+// Resamples the field if the view moved or enough frames have passed.
+func (f *GravityField) Update(sim *Simulation, view twodee.Rectangle) {
+	f.frames++
+	if view == f.view && f.frames < GravityFieldInterval {
+		return
+	}
+	f.frames = 0
+	f.view = view
+	f.sample(sim)
+}
+
+func (f *GravityField) sample(sim *Simulation) {
+	var (
+		w      = f.view.Max.X - f.view.Min.X
+		h      = f.view.Max.Y - f.view.Min.Y
+		cols   = GravityFieldColumns
+		rows   = int(math.Ceil(float64(cols) * float64(h/w)))
+		cell   = twodee.Pt(w/float32(cols), h/float32(rows))
+		colors = make([][]color.RGBA, cols+1)
+	)
+	for i := 0; i <= cols; i++ {
+		colors[i] = make([]color.RGBA, rows+1)
+		for j := 0; j <= rows; j++ {
+			var pt = twodee.Pt(f.view.Min.X+float32(i)*cell.X, f.view.Min.Y+float32(j)*cell.Y)
+			colors[i][j] = wellColor(sim.PotentialAt(pt))
+		}
+	}
+	f.vertices = f.vertices[:0]
+	for i := 0; i < cols; i++ {
+		for j := 0; j < rows; j++ {
+			var (
+				x0 = f.view.Min.X + float32(i)*cell.X
+				y0 = f.view.Min.Y + float32(j)*cell.Y
+				a  = ShapeVertex{twodee.Pt(x0, y0), colors[i][j]}
+				b  = ShapeVertex{twodee.Pt(x0+cell.X, y0), colors[i+1][j]}
+				c  = ShapeVertex{twodee.Pt(x0+cell.X, y0+cell.Y), colors[i+1][j+1]}
+				d  = ShapeVertex{twodee.Pt(x0, y0+cell.Y), colors[i][j+1]}
+			)
+			f.vertices = append(f.vertices, a, b, c, a, c, d)
+		}
+	}
+}
+
+func (f *GravityField) Vertices() []ShapeVertex {
+	return f.vertices
+}
+
+// Blends from a faint blue in open space to orange deep in a well.
+func wellColor(potential float64) color.RGBA {
+	var t = (math.Log10(potential) - gravityFieldLogMin) / (gravityFieldLogMax - gravityFieldLogMin)
+	if math.IsNaN(t) || t < 0 {
+		t = 0
+	}
+	if t > 1 {
+		t = 1
+	}
+	var mix = func(a, b uint8) uint8 {
+		return uint8(float64(a) + (float64(b)-float64(a))*t)
+	}
+	return color.RGBA{
+		mix(shallowWellColor.R, deepWellColor.R),
+		mix(shallowWellColor.G, deepWellColor.G),
+		mix(shallowWellColor.B, deepWellColor.B),
+		mix(shallowWellColor.A, deepWellColor.A),
+	}
+}
@@ -0,0 +1,126 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"time"
+)
+
+const (
+	highScoresFileName = "highscores.json"
+	// Number of entries kept on disk.
+	highScoresMax = 10
+)
+
+type HighScoreEntry struct {
+	Name            string
+	Score           int
+	MaxPopulation   int
+	PlanetsLaunched int
+	PlanetsLost     int
+	Cheevos         int
+	Rewinds         int
+	Ranked          bool
+	Seed            int64
+	Date            time.Time
+}
+
+type HighScores struct {
+	Entries []*HighScoreEntry
+	path    string
+}
+
+type byScore []*HighScoreEntry
+
+func (s byScore) Len() int      { return len(s) }
+func (s byScore) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s byScore) Less(i, j int) bool {
+	if s[i].Score == s[j].Score {
+		return s[i].MaxPopulation > s[j].MaxPopulation
+	}
+	return s[i].Score > s[j].Score
+}
+
+// Loads the table from the user data directory. The table returned is usable
+// even if there is an error, starting empty if it could not be read.
+func LoadHighScores() (scores *HighScores, err error) {
+	var (
+		data   []byte
+		loaded HighScores
+	)
+	scores = &HighScores{
+		Entries: []*HighScoreEntry{},
+	}
+	if scores.path, err = userDataPath(highScoresFileName); err != nil {
+		return
+	}
+	if data, err = ioutil.ReadFile(scores.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	// Parse into a copy so that a bad file leaves the table empty.
+	loaded = *scores
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		err = fmt.Errorf("Could not parse high scores %v: %v", scores.path, err)
+		return
+	}
+	*scores = loaded
+	return
+}
+
+func (h *HighScores) Save() (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(h, "", "  "); err != nil {
+		return
+	}
+	return ioutil.WriteFile(h.path, data, 0644)
+}
+
+// Adds an entry to the table and returns its 1-based rank, or 0 if it did not
+// make the cut.
+func (h *HighScores) Add(entry *HighScoreEntry) (rank int) {
+	h.Entries = append(h.Entries, entry)
+	sort.Stable(byScore(h.Entries))
+	if len(h.Entries) > highScoresMax {
+		h.Entries = h.Entries[:highScoresMax]
+	}
+	for i, e := range h.Entries {
+		if e == entry {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// Returns display lines for the best count entries.
+func (h *HighScores) Lines(count int) []string {
+	var lines = []string{}
+	if len(h.Entries) == 0 {
+		return append(lines, "NO HIGH SCORES YET")
+	}
+	for i, e := range h.Entries {
+		if i >= count {
+			break
+		}
+		lines = append(lines, fmt.Sprintf(
+			"%d. %v  %d  (PEAK %d, %d/%d PLANETS, %d CHEEVOS)  %v",
+			i+1,
+			e.Name,
+			e.Score,
+			e.MaxPopulation,
+			e.PlanetsLaunched-e.PlanetsLost,
+			e.PlanetsLaunched,
+			e.Cheevos,
+			e.Date.Format("2006-01-02"),
+		))
+	}
+	return lines
+}
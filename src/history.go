@@ -0,0 +1,268 @@
+package main
+
+import (
+	"image/color"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	PopulationSampleInterval = time.Second
+	// Samples shown in the HUD sparkline.
+	SparklineSamples = 120
+)
+
+var (
+	sparklineColor = color.RGBA{255, 240, 120, 200}
+	chartAxisColor = color.RGBA{255, 255, 255, 120}
+	planetColors   = []color.RGBA{
+		color.RGBA{120, 200, 255, 200},
+		color.RGBA{120, 255, 160, 200},
+		color.RGBA{255, 220, 120, 200},
+		color.RGBA{220, 140, 255, 200},
+		color.RGBA{255, 150, 150, 200},
+		color.RGBA{150, 255, 240, 200},
+	}
+	deathColors = map[PlanetaryState]color.RGBA{
+		Exploding: color.RGBA{255, 60, 60, 230},
+		Colliding: color.RGBA{255, 160, 40, 230},
+		Escaped:   color.RGBA{80, 140, 255, 230},
+	}
+)
+
+type PlanetPopulation struct {
+	PlanetId   int
+	Population int
+}
+
+type PopulationSample struct {
+	When    time.Duration
+	Total   int
+	Planets []PlanetPopulation
+}
+
+type PlanetDeath struct {
+	When     time.Duration
+	PlanetId int
+	Name     string
+	Cause    PlanetaryState
+}
+
+// PopulationHistory samples the population of the system at a fixed interval
+// and remembers when each planet died.
+type PopulationHistory struct {
+	Samples []PopulationSample
+	Deaths  []PlanetDeath
+	clock   time.Duration
+	elapsed time.Duration
+}
+
+func NewPopulationHistory() *PopulationHistory {
+	return &PopulationHistory{
+		Samples: []PopulationSample{},
+		Deaths:  []PlanetDeath{},
+	}
+}
+
+func (h *PopulationHistory) Update(elapsed time.Duration, planets []*PlanetaryBody) {
+	h.clock += elapsed
+	h.elapsed += elapsed
+	if h.elapsed < PopulationSampleInterval {
+		return
+	}
+	h.elapsed -= PopulationSampleInterval
+	var sample = PopulationSample{
+		When:    h.clock,
+		Planets: []PlanetPopulation{},
+	}
+	for _, p := range planets {
+		if !p.IsAlive() {
+			continue
+		}
+		sample.Total += p.GetPopulation()
+		sample.Planets = append(sample.Planets, PlanetPopulation{p.Id, p.GetPopulation()})
+	}
+	h.Samples = append(h.Samples, sample)
+}
+
+// Cause is the state the planet died in: Exploding, Colliding or Escaped.
+func (h *PopulationHistory) RecordDeath(p *PlanetaryBody, cause PlanetaryState) {
+	h.Deaths = append(h.Deaths, PlanetDeath{h.clock, p.Id, p.Name, cause})
+}
+
+func (h *PopulationHistory) MaxTotal() (most int) {
+	for _, s := range h.Samples {
+		if s.Total > most {
+			most = s.Total
+		}
+	}
+	return
+}
+
+// Returns the id of every planet that appears in the samples, in order of
+// birth.
+func (h *PopulationHistory) planets() (ids []int) {
+	var seen = map[int]bool{}
+	for _, s := range h.Samples {
+		for _, pp := range s.Planets {
+			if !seen[pp.PlanetId] {
+				seen[pp.PlanetId] = true
+				ids = append(ids, pp.PlanetId)
+			}
+		}
+	}
+	return
+}
+
+// Returns a line through the most recent total populations, fitted to r.
+func (h *PopulationHistory) SparklineVertices(r twodee.Rectangle) []ShapeVertex {
+	var (
+		samples  = h.Samples
+		vertices = []ShapeVertex{}
+		peak     = 0
+	)
+	if len(samples) > SparklineSamples {
+		samples = samples[len(samples)-SparklineSamples:]
+	}
+	for _, s := range samples {
+		if s.Total > peak {
+			peak = s.Total
+		}
+	}
+	if len(samples) < 2 || peak == 0 {
+		return vertices
+	}
+	var dx = (r.Max.X - r.Min.X) / float32(SparklineSamples-1)
+	for i, s := range samples {
+		vertices = append(vertices, ShapeVertex{
+			twodee.Pt(r.Min.X+float32(i)*dx, r.Min.Y+(r.Max.Y-r.Min.Y)*float32(s.Total)/float32(peak)),
+			sparklineColor,
+		})
+	}
+	return vertices
+}
+
+// Returns triangles stacking each planet's population over the whole game,
+// fitted to r.
+func (h *PopulationHistory) AreaVertices(r twodee.Rectangle) []ShapeVertex {
+	var (
+		vertices = []ShapeVertex{}
+		peak     = h.MaxTotal()
+		n        = len(h.Samples)
+	)
+	if n < 2 || peak == 0 {
+		return vertices
+	}
+	var (
+		planets = h.planets()
+		// Running total of the layers below each planet, per sample.
+		base = make([]int, n)
+		x    = func(i int) float32 {
+			return r.Min.X + (r.Max.X-r.Min.X)*float32(i)/float32(n-1)
+		}
+		y = func(pop int) float32 {
+			return r.Min.Y + (r.Max.Y-r.Min.Y)*float32(pop)/float32(peak)
+		}
+	)
+	for k, id := range planets {
+		var (
+			c   = planetColors[k%len(planetColors)]
+			top = make([]int, n)
+		)
+		for i, s := range h.Samples {
+			top[i] = base[i]
+			for _, pp := range s.Planets {
+				if pp.PlanetId == id {
+					top[i] += pp.Population
+					break
+				}
+			}
+		}
+		for i := 0; i < n-1; i++ {
+			var (
+				a = ShapeVertex{twodee.Pt(x(i), y(base[i])), c}
+				b = ShapeVertex{twodee.Pt(x(i+1), y(base[i+1])), c}
+				d = ShapeVertex{twodee.Pt(x(i+1), y(top[i+1])), c}
+				e = ShapeVertex{twodee.Pt(x(i), y(top[i])), c}
+			)
+			vertices = append(vertices, a, b, d, a, d, e)
+		}
+		base = top
+	}
+	return vertices
+}
+
+// Returns the chart's axes and a vertical line at each death, coloured by
+// its cause.
+func (h *PopulationHistory) AnnotationVertices(r twodee.Rectangle) []ShapeVertex {
+	var vertices = []ShapeVertex{
+		ShapeVertex{twodee.Pt(r.Min.X, r.Max.Y), chartAxisColor},
+		ShapeVertex{twodee.Pt(r.Min.X, r.Min.Y), chartAxisColor},
+		ShapeVertex{twodee.Pt(r.Min.X, r.Min.Y), chartAxisColor},
+		ShapeVertex{twodee.Pt(r.Max.X, r.Min.Y), chartAxisColor},
+	}
+	if len(h.Samples) < 2 {
+		return vertices
+	}
+	var (
+		start = h.Samples[0].When
+		span  = h.Samples[len(h.Samples)-1].When - start
+	)
+	for _, d := range h.Deaths {
+		if d.When < start {
+			continue
+		}
+		var x = r.Min.X + (r.Max.X-r.Min.X)*float32(d.When-start)/float32(span)
+		if x > r.Max.X {
+			x = r.Max.X
+		}
+		vertices = append(vertices,
+			ShapeVertex{twodee.Pt(x, r.Min.Y), deathColors[d.Cause]},
+			ShapeVertex{twodee.Pt(x, r.Max.Y), deathColors[d.Cause]},
+		)
+	}
+	return vertices
+}
+
+type historySnapshot struct {
+	samples int
+	deaths  int
+	clock   time.Duration
+	elapsed time.Duration
+}
+
+func (h *PopulationHistory) snapshot() historySnapshot {
+	return historySnapshot{len(h.Samples), len(h.Deaths), h.clock, h.elapsed}
+}
+
+// Forgets everything recorded since the snapshot.
+func (h *PopulationHistory) restore(snap historySnapshot) {
+	h.Samples = h.Samples[:snap.samples]
+	h.Deaths = h.Deaths[:snap.deaths]
+	h.clock = snap.clock
+	h.elapsed = snap.elapsed
+}
+
+// Returns how much a planet's population changed over the last n samples, or
+// since it was born if that is more recent.
+func (h *PopulationHistory) Trend(id int, n int) int {
+	var (
+		first, last int
+		found       bool
+	)
+	for i := len(h.Samples) - 1; i >= 0 && i >= len(h.Samples)-1-n; i-- {
+		for _, pp := range h.Samples[i].Planets {
+			if pp.PlanetId != id {
+				continue
+			}
+			if !found {
+				last = pp.Population
+				found = true
+			}
+			first = pp.Population
+			break
+		}
+	}
+	return last - first
+}
@@ -1,28 +1,45 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import (
 	"fmt"
 	"image/color"
+	"math"
+	"strings"
 	"time"
 
 	twodee "../libs/twodee"
 )
 
+// Slots for each planet's text caches.
+const (
+	planetNameSlot = iota
+	planetPopSlot
+	planetWarnSlot
+)
+
 type HudLayer struct {
-	text            *twodee.TextRenderer
-	regularFont     *twodee.FontFace
-	planetFont      *twodee.FontFace
-	messageFont     *twodee.FontFace
-	messageText     *twodee.TextCache
-	messageCoords   twodee.Point
-	globalText      *twodee.TextCache
-	timeText        *twodee.TextCache
-	tempText        map[int]*twodee.TextCache
-	popText         map[int]*twodee.TextCache
-	bounds          twodee.Rectangle
-	App             *Application
-	game            *GameLayer
-	messageListener int
+	text          *twodee.TextRenderer
+	shapes        *ShapeRenderer
+	regularFont   *twodee.FontFace
+	planetFont    *twodee.FontFace
+	messageFont   *twodee.FontFace
+	messageText   *twodee.TextCache
+	messageCoords twodee.Point
+	globalText    *twodee.TextCache
+	timeText      *twodee.TextCache
+	speedText     *twodee.TextCache
+	debugText     *twodee.TextCache
+	planetText    *EntityTextCaches
+	detailText    map[int]*twodee.TextCache
+	census        *CensusPanel
+	labels        map[int]*Label
+	mouse         twodee.Point
+	bounds        twodee.Rectangle
+	App           *Application
+	game          *GameLayer
 }
 
 func NewHudLayer(app *Application, game *GameLayer) (layer *HudLayer, err error) {
@@ -47,15 +64,21 @@ func NewHudLayer(app *Application, game *GameLayer) (layer *HudLayer, err error)
 		regularFont: regularFont,
 		planetFont:  planetFont,
 		messageFont: messageFont,
-		tempText:    map[int]*twodee.TextCache{},
-		popText:     map[int]*twodee.TextCache{},
+		planetText:  NewEntityTextCaches(),
+		detailText:  map[int]*twodee.TextCache{},
+		labels:      map[int]*Label{},
 		globalText:  twodee.NewTextCache(regularFont),
 		timeText:    twodee.NewTextCache(regularFont),
+		speedText:   twodee.NewTextCache(regularFont),
+		debugText:   twodee.NewTextCache(planetFont),
 		messageText: twodee.NewTextCache(messageFont),
 		App:         app,
 		bounds:      app.WinBounds,
 		game:        game,
 	}
+	if layer.census, err = NewCensusPanel(app.WinBounds); err != nil {
+		return
+	}
 	err = layer.Reset()
 	return
 }
@@ -64,24 +87,27 @@ func (l *HudLayer) Delete() {
 	if l.text != nil {
 		l.text.Delete()
 	}
-	for _, v := range l.tempText {
-		v.Delete()
+	if l.shapes != nil {
+		l.shapes.Delete()
 	}
-	for _, v := range l.popText {
+	l.planetText.Delete()
+	for _, v := range l.detailText {
 		v.Delete()
 	}
 	l.globalText.Delete()
 	l.timeText.Delete()
+	l.speedText.Delete()
+	l.debugText.Delete()
 	l.messageText.Delete()
-	l.App.GameEventHandler.RemoveObserver(DisplayMessage, l.messageListener)
+	if l.census != nil {
+		l.census.Delete()
+	}
+	l.App.Events.Release(l)
 }
 
 func (l *HudLayer) Render() {
 	var (
-		textCache     *twodee.TextCache
-		planetPos     twodee.Point
-		screenPos     twodee.Point
-		adjust        twodee.Point
+		label         *Label
 		ok            bool
 		text          string
 		x, y          float32
@@ -90,6 +116,14 @@ func (l *HudLayer) Render() {
 		aggPopulation = l.game.Sim.GetPopulation()
 		maxPopulation = l.game.Sim.GetMaxPopulation()
 	)
+	l.layoutLabels()
+
+	// Sparkline of recent population, under the population count.
+	l.shapes.Bind()
+	l.shapes.DrawLineStrip(l.game.Sim.History.SparklineVertices(twodee.Rect(5, maxY-75, 205, maxY-35)))
+	l.shapes.DrawLines(l.leaderVertices())
+	l.shapes.Unbind()
+
 	l.text.Bind()
 
 	// Display Aggregate Population Count
@@ -117,38 +151,219 @@ func (l *HudLayer) Render() {
 		l.text.Draw(l.timeText.Texture, x, y)
 	}
 
-	//Display Individual Planet Population Counts
-	for p, planet := range l.game.Sim.Planets {
-		planetPos = planet.Pos()
-		if textCache, ok = l.popText[p]; !ok {
-			textCache = twodee.NewTextCache(l.planetFont)
-			l.popText[p] = textCache
-		}
-		textCache.SetText(fmt.Sprintf("%d PEOPLE", planet.GetPopulation()))
-		if textCache.Texture != nil {
-			adjust = twodee.Pt(planet.Radius+0.1, -planet.Radius-0.1)
-			screenPos = l.game.WorldToScreenCoords(planetPos.Add(adjust))
-			l.text.Draw(textCache.Texture, screenPos.X, screenPos.Y-float32(textCache.Texture.Height))
-		}
-		//Display Individual Planet Temperatures
-		if textCache, ok = l.tempText[p]; !ok {
-			textCache = twodee.NewTextCache(l.regularFont)
-			l.tempText[p] = textCache
+	// Display simulation speed, left of the clock.
+	switch {
+	case l.game.IsRewinding():
+		text = "REWINDING"
+	case l.game.IsUserPaused():
+		text = "PAUSED"
+	case l.game.TimeScale() != 1:
+		text = fmt.Sprintf("%vX", l.game.TimeScale())
+	default:
+		text = ""
+	}
+	l.speedText.SetText(text)
+	if text != "" && l.speedText.Texture != nil {
+		y = maxY - float32(l.speedText.Texture.Height)
+		x = maxX - 80.0 - float32(l.speedText.Texture.Width)
+		l.text.Draw(l.speedText.Texture, x, y)
+	}
+
+	//Display Individual Planet Population Counts and Temperatures
+	for _, planet := range l.game.Sim.Planets {
+		if label, ok = l.labels[planet.Id]; !ok {
+			continue
 		}
-		textCache.SetText(fmt.Sprintf("%v %d°F", planet.Name, planet.GetTemperature()))
-		if textCache.Texture != nil {
-			adjust = twodee.Pt(planet.Radius+0.1, planet.Radius+0.1)
-			screenPos = l.game.WorldToScreenCoords(planetPos.Add(adjust))
-			l.text.Draw(textCache.Texture, screenPos.X, screenPos.Y)
+		var (
+			pop  = l.planetText.Get(planet.Id, planetPopSlot, l.planetFont).Texture
+			name = l.planetText.Get(planet.Id, planetNameSlot, l.regularFont).Texture
+		)
+		l.text.Draw(name, label.Pos.X, label.Pos.Y+float32(pop.Height))
+		l.text.Draw(pop, label.Pos.X, label.Pos.Y)
+	}
+	l.renderWarnings()
+	// Anything not drawn this frame belongs to a planet that is gone.
+	l.planetText.Sweep()
+	if l.App.Debug {
+		l.debugText.SetText(fmt.Sprintf("PLANET TEXTURES: %d", l.planetText.Textures()))
+		if l.debugText.Texture != nil {
+			l.text.Draw(l.debugText.Texture, 5, maxY-100)
 		}
 	}
 	if l.messageText.Texture != nil {
 		l.text.Draw(l.messageText.Texture, l.messageCoords.X, l.messageCoords.Y)
 	}
+	if selected := l.game.Selected(); selected != nil {
+		l.renderDetails(selected)
+	}
 	l.text.Unbind()
+	if l.census.Visible {
+		l.census.Render(l.text, l.shapes, l.game.Sim, l.game.SelectedId)
+	}
+}
+
+// Measures each on screen planet's labels and lays them out so that they
+// neither overlap each other nor leave the screen. Nothing is labelled while
+// the census is open, since it lists the same details.
+func (l *HudLayer) layoutLabels() {
+	var labels = []*Label{}
+	l.labels = map[int]*Label{}
+	if l.census.Visible {
+		return
+	}
+	for _, planet := range l.game.Sim.Planets {
+		var (
+			popCache  = l.planetText.Get(planet.Id, planetPopSlot, l.planetFont)
+			nameCache = l.planetText.Get(planet.Id, planetNameSlot, l.regularFont)
+		)
+		popCache.SetText(fmt.Sprintf("%d PEOPLE", planet.GetPopulation()))
+		nameCache.SetText(fmt.Sprintf("%v %d°F", planet.Name, planet.GetTemperature()))
+		var (
+			pop    = popCache.Texture
+			temp   = nameCache.Texture
+			anchor = l.game.WorldToScreenCoords(planet.Pos())
+			edge   = l.game.WorldToScreenCoords(planet.Pos().Add(twodee.Pt(planet.Radius, 0)))
+		)
+		if pop == nil || temp == nil || !l.bounds.ContainsPoint(anchor) {
+			continue
+		}
+		var label = &Label{
+			Anchor:   anchor,
+			Radius:   edge.X - anchor.X,
+			Size:     twodee.Pt(float32(math.Max(float64(pop.Width), float64(temp.Width))), float32(pop.Height+temp.Height)),
+			Priority: planet.GetPopulation(),
+		}
+		// The selected planet always gets the best spot.
+		if planet.Id == l.game.SelectedId {
+			label.Priority = math.MaxInt32
+		}
+		l.labels[planet.Id] = label
+		labels = append(labels, label)
+	}
+	LayoutLabels(labels, l.bounds)
+}
+
+// Returns a line to each label that had to be moved away from its planet.
+func (l *HudLayer) leaderVertices() []ShapeVertex {
+	var vertices = []ShapeVertex{}
+	for _, label := range l.labels {
+		if !label.Displaced {
+			continue
+		}
+		var from, to = label.Leader()
+		vertices = append(vertices, ShapeVertex{from, leaderColor}, ShapeVertex{to, leaderColor})
+	}
+	return vertices
+}
+
+// Counts down beside each planet that is about to leave the system, or beside
+// its edge arrow if it is off screen.
+func (l *HudLayer) renderWarnings() {
+	var (
+		textCache *twodee.TextCache
+		label     *Label
+		ok        bool
+		screenPos twodee.Point
+		text      string
+		x, y      float32
+	)
+	for _, indicator := range l.game.Indicators() {
+		if !indicator.Escaping {
+			continue
+		}
+		var secs = int64(math.Ceil(indicator.EscapeIn.Seconds()))
+		if indicator.Offscreen {
+			text = fmt.Sprintf("%v ESCAPING IN %dS", indicator.Planet.Name, secs)
+		} else {
+			text = fmt.Sprintf("ESCAPING IN %dS", secs)
+		}
+		textCache = l.planetText.Get(indicator.Planet.Id, planetWarnSlot, l.planetFont)
+		textCache.SetText(text)
+		if textCache.Texture == nil {
+			continue
+		}
+		var (
+			w = float32(textCache.Texture.Width)
+			h = float32(textCache.Texture.Height)
+		)
+		if indicator.Offscreen {
+			// Sit just inside the arrow, kept on screen.
+			screenPos = l.game.WorldToScreenCoords(indicator.Pos.Sub(indicator.Dir.Scale(2 * indicatorSize / l.game.Camera.Zoom)))
+			x = float32(math.Max(5, math.Min(float64(screenPos.X-w/2), float64(l.bounds.Max.X-w-5))))
+			y = float32(math.Max(5, math.Min(float64(screenPos.Y-h/2), float64(l.bounds.Max.Y-h-5))))
+		} else if label, ok = l.labels[indicator.Planet.Id]; ok {
+			// Below the planet's labels.
+			x, y = label.Pos.X, label.Pos.Y-h
+		} else {
+			var planet = indicator.Planet
+			screenPos = l.game.WorldToScreenCoords(planet.Pos().Add(twodee.Pt(planet.Radius+0.1, -planet.Radius-0.1)))
+			x, y = screenPos.X, screenPos.Y-h
+		}
+		l.text.Draw(textCache.Texture, x, y)
+	}
+}
+
+// Lists everything known about a planet in the bottom left corner.
+func (l *HudLayer) renderDetails(planet *PlanetaryBody) {
+	var (
+		textCache *twodee.TextCache
+		ok        bool
+		y         float32 = 5
+		name              = planet.Name
+		tools             = []string{}
+		lines     []string
+	)
+	if l.game.Renaming != nil {
+		name = fmt.Sprintf("RENAME: %v_", l.game.Renaming.Value)
+	}
+	for i, tool := range l.game.Tools.Tools {
+		tools = append(tools, fmt.Sprintf("%v %v", l.game.App.Keys.Describe(ActionTool1+InputAction(i)), tool.Label()))
+	}
+	lines = []string{
+		strings.Join(tools, "   "),
+		name,
+		fmt.Sprintf("MASS: %.0f", planet.Mass),
+		fmt.Sprintf("VELOCITY: %.1f", planet.Velocity.DistanceTo(twodee.Pt(0, 0))*1000),
+		fmt.Sprintf("DISTANCE TO SOL: %.1f", planet.DistToSun),
+		fmt.Sprintf("TEMPERATURE: %d°F", planet.GetTemperature()),
+		fmt.Sprintf("POPULATION: %d", planet.GetPopulation()),
+		fmt.Sprintf("AGE: %ds", int64(planet.Age.Seconds())),
+	}
+	// Draw from the bottom up.
+	for i := len(lines) - 1; i >= 0; i-- {
+		if textCache, ok = l.detailText[i]; !ok {
+			textCache = twodee.NewTextCache(l.planetFont)
+			l.detailText[i] = textCache
+		}
+		textCache.SetText(lines[i])
+		if textCache.Texture != nil {
+			l.text.Draw(textCache.Texture, 5, y)
+			y += float32(textCache.Texture.Height)
+		}
+	}
 }
 
 func (l *HudLayer) HandleEvent(evt twodee.Event) bool {
+	switch event := evt.(type) {
+	case *twodee.KeyEvent:
+		if event.Type == twodee.Press && l.game.App.Keys.Is(ActionCensus, event.Code) && l.game.Renaming == nil {
+			l.census.Toggle()
+			return false
+		}
+	case *twodee.MouseMoveEvent:
+		// Text is drawn with the origin in the bottom left.
+		l.mouse = twodee.Pt(event.X, l.bounds.Max.Y-event.Y)
+	case *twodee.MouseButtonEvent:
+		if !l.census.Visible || event.Type != twodee.Press || event.Button != twodee.MouseButtonLeft {
+			break
+		}
+		if planet, hit := l.census.Click(l.mouse.X, l.mouse.Y); hit {
+			if planet != nil {
+				l.game.SelectPlanet(planet)
+			}
+			return false
+		}
+	}
 	return true
 }
 
@@ -160,23 +375,30 @@ func (l *HudLayer) Reset() (err error) {
 	if l.text, err = twodee.NewTextRenderer(l.bounds); err != nil {
 		return
 	}
-	l.messageListener = l.App.GameEventHandler.AddObserver(DisplayMessage, l.OnDisplayMessage)
+	if l.shapes, err = NewShapeRenderer(l.bounds); err != nil {
+		return
+	}
+	l.App.Events.OnMessage(l, l.OnDisplayMessage)
+	l.App.Events.OnPlanet(l, PlanetEscaped, l.OnPlanetEscaped)
 	return
 }
 
-func (l *HudLayer) OnDisplayMessage(evt twodee.GETyper) {
-	switch event := evt.(type) {
-	case *DisplayMessageEvent:
-		l.messageText.SetText(event.Message)
-		if l.messageText.Texture != nil {
-			if event.Positioned {
-				l.messageCoords = l.game.WorldToScreenCoords(event.Coords)
-			} else {
-				l.messageCoords = twodee.Point{
-					(l.bounds.Max.X - float32(l.messageText.Texture.OriginalWidth)) / 2.0,
-					(l.bounds.Max.Y - float32(l.messageText.Texture.OriginalHeight)) / 4.0,
-				}
+func (l *HudLayer) OnDisplayMessage(event *DisplayMessageEvent) {
+	l.messageText.SetText(event.Message)
+	if l.messageText.Texture != nil {
+		if event.Positioned {
+			l.messageCoords = l.game.WorldToScreenCoords(event.Coords)
+		} else {
+			l.messageCoords = twodee.Point{
+				(l.bounds.Max.X - float32(l.messageText.Texture.OriginalWidth)) / 2.0,
+				(l.bounds.Max.Y - float32(l.messageText.Texture.OriginalHeight)) / 4.0,
 			}
 		}
 	}
 }
+
+// Shown directly rather than through a DisplayMessage event, so that the
+// event log records the escape once, from the PlanetEscaped event itself.
+func (l *HudLayer) OnPlanetEscaped(event *PlanetEvent) {
+	l.OnDisplayMessage(NewMessageEvent(fmt.Sprintf("%v WAS LOST TO THE VOID", event.Planet.Name)))
+}
@@ -0,0 +1,123 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// Planets due to leave the system within this long get a countdown.
+	EscapeWarningTime = 10 * time.Second
+	// Size of an edge arrow and its gap from the edge, in world units at 1x
+	// zoom.
+	indicatorSize   = 1.2
+	indicatorMargin = 1.5
+)
+
+var (
+	indicatorColor = color.RGBA{200, 200, 255, 180}
+	escapingColor  = color.RGBA{255, 80, 80, 220}
+)
+
+// PlanetIndicator marks a planet that is off screen or about to escape.
+type PlanetIndicator struct {
+	Planet    *PlanetaryBody
+	Pos       twodee.Point
+	Dir       twodee.Point
+	Offscreen bool
+	Escaping  bool
+	EscapeIn  time.Duration
+}
+
+// Returns an indicator for every living planet that is either outside the
+// view, in which case it is pinned to the view's edge, or about to escape.
+func (l *GameLayer) Indicators() []PlanetIndicator {
+	var (
+		indicators = []PlanetIndicator{}
+		margin     = indicatorMargin / l.Camera.Zoom
+		inner      = twodee.Rect(
+			l.viewBounds.Min.X+margin,
+			l.viewBounds.Min.Y+margin,
+			l.viewBounds.Max.X-margin,
+			l.viewBounds.Max.Y-margin,
+		)
+	)
+	for _, p := range l.Sim.Planets {
+		if !p.IsAlive() {
+			continue
+		}
+		var indicator = PlanetIndicator{
+			Planet:    p,
+			Pos:       p.Pos(),
+			Offscreen: !l.viewBounds.ContainsPoint(p.Pos()),
+		}
+		if d, ok := l.Sim.TimeToEscape(p); ok && d < EscapeWarningTime {
+			indicator.Escaping = true
+			indicator.EscapeIn = d
+		}
+		if !indicator.Offscreen && !indicator.Escaping {
+			continue
+		}
+		if indicator.Offscreen {
+			indicator.Pos, indicator.Dir = edgePoint(inner, p.Pos())
+		}
+		indicators = append(indicators, indicator)
+	}
+	return indicators
+}
+
+// Returns where the line from the centre of r to pt meets the edge of r,
+// and the direction of that line.
+func edgePoint(r twodee.Rectangle, pt twodee.Point) (edge, dir twodee.Point) {
+	var (
+		center = twodee.Pt((r.Min.X+r.Max.X)/2, (r.Min.Y+r.Max.Y)/2)
+		d      = pt.Sub(center)
+		length = d.DistanceTo(twodee.Pt(0, 0))
+		scale  = math.Inf(1)
+	)
+	if length == 0 {
+		return center, twodee.Pt(1, 0)
+	}
+	if d.X != 0 {
+		scale = math.Min(scale, math.Abs(float64((r.Max.X-center.X)/d.X)))
+	}
+	if d.Y != 0 {
+		scale = math.Min(scale, math.Abs(float64((r.Max.Y-center.Y)/d.Y)))
+	}
+	return center.Add(d.Scale(float32(scale))), d.Scale(1 / length)
+}
+
+// Draws an arrow on the edge of the screen toward each off screen planet.
+func (l *GameLayer) drawIndicators() {
+	var (
+		size      = indicatorSize / l.Camera.Zoom
+		triangles = []ShapeVertex{}
+	)
+	for _, indicator := range l.Indicators() {
+		if !indicator.Offscreen {
+			continue
+		}
+		var (
+			c    = indicatorColor
+			dir  = indicator.Dir
+			perp = twodee.Pt(-dir.Y, dir.X)
+			tip  = indicator.Pos.Add(dir.Scale(size))
+			back = indicator.Pos.Sub(dir.Scale(size / 2))
+		)
+		if indicator.Escaping {
+			c = escapingColor
+		}
+		triangles = append(triangles,
+			ShapeVertex{tip, c},
+			ShapeVertex{back.Add(perp.Scale(size * 0.6)), c},
+			ShapeVertex{back.Sub(perp.Scale(size * 0.6)), c},
+		)
+	}
+	l.ShapeRenderer.DrawTriangles(triangles)
+}
@@ -0,0 +1,380 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	twodee "../libs/twodee"
+)
+
+const controlsFileName = "controls.json"
+
+// InputAction is something the player can do with a key.
+type InputAction int
+
+const (
+	ActionMenu InputAction = iota
+	ActionConfirm
+	ActionMenuUp
+	ActionMenuDown
+	ActionPause
+	ActionStep
+	ActionSpeedUp
+	ActionSlowDown
+	ActionRewind
+	ActionPanLeft
+	ActionPanRight
+	ActionPanUp
+	ActionPanDown
+	ActionZoomIn
+	ActionZoomOut
+	ActionFollow
+	ActionTool1
+	ActionTool2
+	ActionTool3
+	ActionOrbits
+	ActionGravity
+	ActionCensus
+	ActionLog
+	ActionLogUp
+	ActionLogDown
+	numInputActions
+)
+
+// InputContext is a set of situations an action can be used in. Two actions
+// may share a key only if they are never used in the same situation.
+type InputContext int
+
+const (
+	ContextPlay InputContext = 1 << iota
+	ContextMenu
+	// While the event log is open.
+	ContextLog
+)
+
+// The sections of the controls menu.
+const (
+	sectionTime = iota
+	sectionCamera
+	sectionView
+	sectionMenus
+	numControlSections
+)
+
+var controlSectionLabels = [numControlSections]string{
+	"Time",
+	"Camera",
+	"View",
+	"Menus",
+}
+
+type inputActionInfo struct {
+	name     string // Used in the controls file.
+	label    string // Used in the controls menu.
+	section  int
+	contexts InputContext
+	defaults []twodee.KeyCode
+}
+
+var inputActions = [numInputActions]inputActionInfo{
+	{"menu", "Menu", sectionMenus, ContextPlay | ContextMenu | ContextLog, []twodee.KeyCode{twodee.KeyEscape}},
+	{"confirm", "Confirm", sectionMenus, ContextPlay | ContextMenu, []twodee.KeyCode{twodee.KeyEnter}},
+	{"menuUp", "Menu Up", sectionMenus, ContextMenu, []twodee.KeyCode{twodee.KeyUp}},
+	{"menuDown", "Menu Down", sectionMenus, ContextMenu, []twodee.KeyCode{twodee.KeyDown}},
+	{"pause", "Pause", sectionTime, ContextPlay, []twodee.KeyCode{twodee.KeyP}},
+	{"step", "Step", sectionTime, ContextPlay, []twodee.KeyCode{twodee.KeyPeriod}},
+	{"speedUp", "Speed Up", sectionTime, ContextPlay, []twodee.KeyCode{twodee.KeyRightBracket, twodee.KeyEqual}},
+	{"slowDown", "Slow Down", sectionTime, ContextPlay, []twodee.KeyCode{twodee.KeyLeftBracket, twodee.KeyMinus}},
+	{"rewind", "Rewind", sectionTime, ContextPlay, []twodee.KeyCode{twodee.KeyR}},
+	{"panLeft", "Pan Left", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyLeft}},
+	{"panRight", "Pan Right", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyRight}},
+	{"panUp", "Pan Up", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyUp}},
+	{"panDown", "Pan Down", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyDown}},
+	{"zoomIn", "Zoom In", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyPageUp}},
+	{"zoomOut", "Zoom Out", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyPageDown}},
+	{"follow", "Follow", sectionCamera, ContextPlay, []twodee.KeyCode{twodee.KeyC}},
+	{"tool1", "Tool 1", sectionView, ContextPlay, []twodee.KeyCode{twodee.Key1}},
+	{"tool2", "Tool 2", sectionView, ContextPlay, []twodee.KeyCode{twodee.Key2}},
+	{"tool3", "Tool 3", sectionView, ContextPlay, []twodee.KeyCode{twodee.Key3}},
+	{"orbits", "Orbits", sectionView, ContextPlay, []twodee.KeyCode{twodee.KeyO}},
+	{"gravity", "Gravity", sectionView, ContextPlay, []twodee.KeyCode{twodee.KeyG}},
+	{"census", "Census", sectionView, ContextPlay, []twodee.KeyCode{twodee.KeyTab}},
+	{"log", "Event Log", sectionView, ContextPlay | ContextLog, []twodee.KeyCode{twodee.KeyL}},
+	{"logUp", "Log Up", sectionView, ContextLog, []twodee.KeyCode{twodee.KeyPageUp}},
+	{"logDown", "Log Down", sectionView, ContextLog, []twodee.KeyCode{twodee.KeyPageDown}},
+}
+
+func (a InputAction) String() string {
+	return inputActions[a].label
+}
+
+var keyNames = map[twodee.KeyCode]string{
+	twodee.KeyA:            "A",
+	twodee.KeyB:            "B",
+	twodee.KeyC:            "C",
+	twodee.KeyD:            "D",
+	twodee.KeyE:            "E",
+	twodee.KeyF:            "F",
+	twodee.KeyG:            "G",
+	twodee.KeyH:            "H",
+	twodee.KeyI:            "I",
+	twodee.KeyJ:            "J",
+	twodee.KeyK:            "K",
+	twodee.KeyL:            "L",
+	twodee.KeyM:            "M",
+	twodee.KeyN:            "N",
+	twodee.KeyO:            "O",
+	twodee.KeyP:            "P",
+	twodee.KeyQ:            "Q",
+	twodee.KeyR:            "R",
+	twodee.KeyS:            "S",
+	twodee.KeyT:            "T",
+	twodee.KeyU:            "U",
+	twodee.KeyV:            "V",
+	twodee.KeyW:            "W",
+	twodee.KeyX:            "X",
+	twodee.KeyY:            "Y",
+	twodee.KeyZ:            "Z",
+	twodee.Key0:            "0",
+	twodee.Key1:            "1",
+	twodee.Key2:            "2",
+	twodee.Key3:            "3",
+	twodee.Key4:            "4",
+	twodee.Key5:            "5",
+	twodee.Key6:            "6",
+	twodee.Key7:            "7",
+	twodee.Key8:            "8",
+	twodee.Key9:            "9",
+	twodee.KeySpace:        "SPACE",
+	twodee.KeyApostrophe:   "'",
+	twodee.KeyComma:        ",",
+	twodee.KeyMinus:        "-",
+	twodee.KeyPeriod:       ".",
+	twodee.KeySlash:        "/",
+	twodee.KeySemicolon:    ";",
+	twodee.KeyEqual:        "=",
+	twodee.KeyLeftBracket:  "[",
+	twodee.KeyBackslash:    "\\",
+	twodee.KeyRightBracket: "]",
+	twodee.KeyGraveAccent:  "`",
+	twodee.KeyEscape:       "ESCAPE",
+	twodee.KeyEnter:        "ENTER",
+	twodee.KeyTab:          "TAB",
+	twodee.KeyBackspace:    "BACKSPACE",
+	twodee.KeyInsert:       "INSERT",
+	twodee.KeyDelete:       "DELETE",
+	twodee.KeyRight:        "RIGHT",
+	twodee.KeyLeft:         "LEFT",
+	twodee.KeyDown:         "DOWN",
+	twodee.KeyUp:           "UP",
+	twodee.KeyPageUp:       "PAGEUP",
+	twodee.KeyPageDown:     "PAGEDOWN",
+	twodee.KeyHome:         "HOME",
+	twodee.KeyEnd:          "END",
+	twodee.KeyF1:           "F1",
+	twodee.KeyF2:           "F2",
+	twodee.KeyF3:           "F3",
+	twodee.KeyF4:           "F4",
+	twodee.KeyF5:           "F5",
+	twodee.KeyF6:           "F6",
+	twodee.KeyF7:           "F7",
+	twodee.KeyF8:           "F8",
+	twodee.KeyF9:           "F9",
+	twodee.KeyF10:          "F10",
+	twodee.KeyF11:          "F11",
+	twodee.KeyF12:          "F12",
+}
+
+func KeyName(code twodee.KeyCode) string {
+	if name, ok := keyNames[code]; ok {
+		return name
+	}
+	return fmt.Sprintf("KEY %d", code)
+}
+
+func parseKeyName(name string) (code twodee.KeyCode, err error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	for code, n := range keyNames {
+		if n == name {
+			return code, nil
+		}
+	}
+	return 0, fmt.Errorf("Unknown key %q", name)
+}
+
+// KeyConflict is a key bound to two actions that can be used at the same
+// time.
+type KeyConflict struct {
+	Key     twodee.KeyCode
+	Action  InputAction
+	Already InputAction
+}
+
+func (c *KeyConflict) Error() string {
+	return fmt.Sprintf("%v IS ALREADY BOUND TO %v", KeyName(c.Key), strings.ToUpper(c.Already.String()))
+}
+
+// KeyBindings maps keys to actions. Bindings are read from the controls
+// file in the user data directory, with defaults for any action it leaves
+// out.
+type KeyBindings struct {
+	keys [numInputActions][]twodee.KeyCode
+	path string
+}
+
+// Loads the bindings from the user data directory. The bindings returned are
+// usable even if there is an error, falling back to the defaults if the
+// controls file could not be read.
+func LoadKeyBindings() (bindings *KeyBindings, err error) {
+	var (
+		path   string
+		data   []byte
+		file   = map[string][]string{}
+		loaded [numInputActions][]twodee.KeyCode
+	)
+	bindings = &KeyBindings{}
+	bindings.Reset()
+	if path, err = userDataPath(controlsFileName); err != nil {
+		return
+	}
+	bindings.path = path
+	if data, err = ioutil.ReadFile(path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	if err = json.Unmarshal(data, &file); err != nil {
+		err = fmt.Errorf("Could not parse controls %v: %v", path, err)
+		return
+	}
+	// Apply nothing unless the whole file parses.
+	loaded = bindings.keys
+	for name, names := range file {
+		var action, ok = findInputAction(name)
+		if !ok {
+			err = fmt.Errorf("Unknown action %q in controls %v", name, path)
+			return
+		}
+		var keys = []twodee.KeyCode{}
+		for _, n := range names {
+			var code twodee.KeyCode
+			if code, err = parseKeyName(n); err != nil {
+				err = fmt.Errorf("Could not parse controls %v: %v", path, err)
+				return
+			}
+			keys = append(keys, code)
+		}
+		loaded[action] = keys
+	}
+	bindings.keys = loaded
+	return
+}
+
+func findInputAction(name string) (action InputAction, ok bool) {
+	for i, info := range inputActions {
+		if info.name == name {
+			return InputAction(i), true
+		}
+	}
+	return
+}
+
+func (b *KeyBindings) Save() (err error) {
+	var (
+		file = map[string][]string{}
+		data []byte
+	)
+	for i, keys := range b.keys {
+		var names = []string{}
+		for _, code := range keys {
+			names = append(names, KeyName(code))
+		}
+		file[inputActions[i].name] = names
+	}
+	if data, err = json.MarshalIndent(file, "", "  "); err != nil {
+		return
+	}
+	return ioutil.WriteFile(b.path, data, 0644)
+}
+
+// Puts every action back on its default keys.
+func (b *KeyBindings) Reset() {
+	for i, info := range inputActions {
+		b.keys[i] = append([]twodee.KeyCode{}, info.defaults...)
+	}
+}
+
+// Returns whether code is bound to action.
+func (b *KeyBindings) Is(action InputAction, code twodee.KeyCode) bool {
+	for _, k := range b.keys[action] {
+		if k == code {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns the action bound to code that can be used in context.
+func (b *KeyBindings) Action(code twodee.KeyCode, context InputContext) (action InputAction, ok bool) {
+	for i, info := range inputActions {
+		if info.contexts&context != 0 && b.Is(InputAction(i), code) {
+			return InputAction(i), true
+		}
+	}
+	return
+}
+
+// Returns the keys bound to action, for display.
+func (b *KeyBindings) Describe(action InputAction) string {
+	var names = []string{}
+	for _, code := range b.keys[action] {
+		names = append(names, KeyName(code))
+	}
+	if len(names) == 0 {
+		return "NONE"
+	}
+	return strings.Join(names, " / ")
+}
+
+// Returns the action that code would clash with if bound to action.
+func (b *KeyBindings) conflict(action InputAction, code twodee.KeyCode) *KeyConflict {
+	for i, info := range inputActions {
+		var other = InputAction(i)
+		if other != action && info.contexts&inputActions[action].contexts != 0 && b.Is(other, code) {
+			return &KeyConflict{code, action, other}
+		}
+	}
+	return nil
+}
+
+// Binds action to code alone, unless that would clash with another action.
+func (b *KeyBindings) Bind(action InputAction, code twodee.KeyCode) error {
+	if c := b.conflict(action, code); c != nil {
+		return c
+	}
+	b.keys[action] = []twodee.KeyCode{code}
+	return nil
+}
+
+// Returns every clash between the current bindings, as a hand edited
+// controls file may have some.
+func (b *KeyBindings) Conflicts() (conflicts []*KeyConflict) {
+	for i, keys := range b.keys {
+		for _, code := range keys {
+			// Check each pair once.
+			for j := i + 1; j < len(inputActions); j++ {
+				if inputActions[i].contexts&inputActions[j].contexts != 0 && b.Is(InputAction(j), code) {
+					conflicts = append(conflicts, &KeyConflict{code, InputAction(j), InputAction(i)})
+				}
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,40 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"testing"
+
+	twodee "../libs/twodee"
+)
+
+func defaultKeyBindings() *KeyBindings {
+	var b = &KeyBindings{}
+	b.Reset()
+	return b
+}
+
+func TestKeyBindingsDefaultsDoNotConflict(t *testing.T) {
+	if conflicts := defaultKeyBindings().Conflicts(); len(conflicts) != 0 {
+		t.Errorf("default bindings conflict: %v", conflicts)
+	}
+}
+
+func TestKeyBindingsLogConflicts(t *testing.T) {
+	var b = defaultKeyBindings()
+	var err = b.Bind(ActionLogUp, twodee.KeyL)
+	if c, ok := err.(*KeyConflict); !ok || c.Already != ActionLog {
+		t.Errorf("binding log up to L: got %v, want a conflict with %v", err, ActionLog)
+	}
+	if !b.Is(ActionLogUp, twodee.KeyPageUp) {
+		t.Errorf("log up lost its binding after a conflict")
+	}
+
+	// As a hand edited controls file could have it.
+	b.keys[ActionLogUp] = []twodee.KeyCode{twodee.KeyL}
+	var conflicts = b.Conflicts()
+	if len(conflicts) != 1 || conflicts[0].Already != ActionLog || conflicts[0].Action != ActionLogUp {
+		t.Errorf("got conflicts %v, want log up with %v", conflicts, ActionLog)
+	}
+}
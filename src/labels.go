@@ -0,0 +1,162 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+	"math"
+	"sort"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// Gap between a planet and its label, in pixels.
+	labelGap = 4
+	// Labels that cannot be placed beside their planet are tried this many
+	// times further out.
+	labelRings = 3
+)
+
+var leaderColor = color.RGBA{255, 255, 255, 140}
+
+// Label is a block of text belonging to a planet, in screen coordinates with
+// the origin in the bottom left.
+type Label struct {
+	Anchor    twodee.Point
+	Radius    float32
+	Size      twodee.Point
+	Priority  int
+	Pos       twodee.Point
+	Displaced bool
+}
+
+func (l *Label) Rect() twodee.Rectangle {
+	return twodee.Rect(l.Pos.X, l.Pos.Y, l.Pos.X+l.Size.X, l.Pos.Y+l.Size.Y)
+}
+
+// Returns the bottom left corners the label may take around its planet,
+// nearest first. The first is the preferred spot, to the right.
+func (l *Label) candidates(ring int) []twodee.Point {
+	var (
+		ax = l.Anchor.X
+		ay = l.Anchor.Y
+		w  = l.Size.X
+		h  = l.Size.Y
+		r  = l.Radius + labelGap + float32(ring)*(l.Size.Y+labelGap)
+		d  = r * 0.7
+	)
+	return []twodee.Point{
+		twodee.Pt(ax+r, ay-h/2),
+		twodee.Pt(ax+d, ay+d),
+		twodee.Pt(ax+d, ay-d-h),
+		twodee.Pt(ax-r-w, ay-h/2),
+		twodee.Pt(ax-d-w, ay+d),
+		twodee.Pt(ax-d-w, ay-d-h),
+		twodee.Pt(ax-w/2, ay+r),
+		twodee.Pt(ax-w/2, ay-r-h),
+	}
+}
+
+// Returns the pixels of the leader line from the edge of the planet to the
+// nearest point of its label.
+func (l *Label) Leader() (from, to twodee.Point) {
+	var rect = l.Rect()
+	to = twodee.Pt(
+		float32(math.Max(float64(rect.Min.X), math.Min(float64(l.Anchor.X), float64(rect.Max.X)))),
+		float32(math.Max(float64(rect.Min.Y), math.Min(float64(l.Anchor.Y), float64(rect.Max.Y)))),
+	)
+	var dist = to.DistanceTo(l.Anchor)
+	if dist == 0 {
+		return l.Anchor, to
+	}
+	from = l.Anchor.Add(to.Sub(l.Anchor).Scale(l.Radius / dist))
+	return
+}
+
+func overlapArea(a, b twodee.Rectangle) float32 {
+	var (
+		w = float32(math.Min(float64(a.Max.X), float64(b.Max.X)) - math.Max(float64(a.Min.X), float64(b.Min.X)))
+		h = float32(math.Min(float64(a.Max.Y), float64(b.Max.Y)) - math.Max(float64(a.Min.Y), float64(b.Min.Y)))
+	)
+	if w <= 0 || h <= 0 {
+		return 0
+	}
+	return w * h
+}
+
+type byPriority []*Label
+
+func (s byPriority) Len() int           { return len(s) }
+func (s byPriority) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s byPriority) Less(i, j int) bool { return s[i].Priority > s[j].Priority }
+
+// Places each label at the first candidate spot that overlaps neither other
+// labels, nor planets, nor the edge of bounds, falling back to the spot with
+// the least overlap. Higher priority labels are placed first. Labels that end
+// up away from their planet are marked as displaced.
+func LayoutLabels(labels []*Label, bounds twodee.Rectangle) {
+	var (
+		placed  = []twodee.Rectangle{}
+		planets = make([]twodee.Rectangle, len(labels))
+		ordered = make([]*Label, len(labels))
+	)
+	for i, l := range labels {
+		planets[i] = twodee.Rect(l.Anchor.X-l.Radius, l.Anchor.Y-l.Radius, l.Anchor.X+l.Radius, l.Anchor.Y+l.Radius)
+	}
+	copy(ordered, labels)
+	sort.Stable(byPriority(ordered))
+	for _, l := range ordered {
+		var (
+			best     twodee.Point
+			bestCost = float32(math.Inf(1))
+			bestRing = 0
+			own      = twodee.Rect(l.Anchor.X-l.Radius, l.Anchor.Y-l.Radius, l.Anchor.X+l.Radius, l.Anchor.Y+l.Radius)
+		)
+	search:
+		for ring := 0; ring < labelRings; ring++ {
+			for _, pos := range l.candidates(ring) {
+				l.Pos = pos
+				var (
+					rect = l.Rect()
+					// Whatever lies outside the bounds counts as overlap.
+					cost = l.Size.X*l.Size.Y - overlapArea(rect, bounds)
+				)
+				for _, other := range placed {
+					cost += overlapArea(rect, other)
+				}
+				for _, planet := range planets {
+					if planet != own {
+						cost += overlapArea(rect, planet)
+					}
+				}
+				if cost < bestCost {
+					best, bestCost, bestRing = pos, cost, ring
+				}
+				if cost == 0 {
+					break search
+				}
+			}
+		}
+		l.Pos = best
+		l.Displaced = bestRing > 0
+		// Clamp into bounds, which may move the label away from its planet.
+		if l.Pos.X < bounds.Min.X {
+			l.Pos.X = bounds.Min.X
+		}
+		if l.Pos.X+l.Size.X > bounds.Max.X {
+			l.Pos.X = bounds.Max.X - l.Size.X
+		}
+		if l.Pos.Y < bounds.Min.Y {
+			l.Pos.Y = bounds.Min.Y
+		}
+		if l.Pos.Y+l.Size.Y > bounds.Max.Y {
+			l.Pos.Y = bounds.Max.Y - l.Size.Y
+		}
+		if l.Pos != best {
+			l.Displaced = true
+		}
+		placed = append(placed, l.Rect())
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"math"
+
+	twodee "../libs/twodee"
+)
+
+// Planets are launched with a drag: the planet appears where the drag starts
+// and is thrown with a velocity proportional to the drag's length.
+const magicVelocityScalingFactor = 1e-3
+
+// Returns a phantom planet at pt, which is not part of the simulation until
+// it is released.
+func (s *Simulation) DropPlanet(pt twodee.Point) *PlanetaryBody {
+	var p = NewPlanet(pt.X, pt.Y, s.Rand, s.names.Next())
+	p.SetState(Phantom)
+	return p
+}
+
+// Throws a phantom planet by a drag ending at pt and adds it to the
+// simulation.
+func (s *Simulation) ReleasePlanet(p *PlanetaryBody, pt twodee.Point) {
+	var pos = p.Pos()
+	// Since the vector's magnitude is still too big, we need to scale it
+	// down by some magic factor.
+	p.Velocity = twodee.Pt(pt.X-pos.X, pt.Y-pos.Y).Scale(magicVelocityScalingFactor)
+	p.RemState(Phantom)
+	s.AddPlanet(p)
+	s.Events.Enqueue(NewPlanetEvent(PlanetBorn, p, s.Tick))
+}
+
+// Returns where a drag starting at pt has to end to put a planet on a
+// circular orbit around the sun, ignoring the other planets.
+func (s *Simulation) CircularDragEnd(pt twodee.Point) twodee.Point {
+	var (
+		rel   = pt.Sub(s.Sun.Pos())
+		r     = float64(pt.DistanceTo(s.Sun.Pos()))
+		speed = math.Sqrt(GravConst * float64(s.Sun.Mass) / r)
+		// Perpendicular to the sun, going anticlockwise.
+		dir = twodee.Pt(-rel.Y, rel.X).Scale(float32(1 / r))
+	)
+	return pt.Add(dir.Scale(float32(speed / magicVelocityScalingFactor)))
+}
+
+// Launcher turns the drop and release of a drag into a planet. The player and
+// each bot have their own, so that their drags cannot get mixed up.
+type Launcher struct {
+	// The planet being dragged, if any.
+	Phantom *PlanetaryBody
+	sim     *Simulation
+	events  *EventBus // Nil unless the launcher listens for events.
+	bot     bool      // Follows the events of a bot rather than the mouse.
+}
+
+func NewLauncher(sim *Simulation) *Launcher {
+	return &Launcher{
+		Phantom: nil,
+		sim:     sim,
+	}
+}
+
+// Makes the launcher follow the DropPlanet and ReleasePlanet events sent for
+// the mouse.
+func (l *Launcher) Listen(events *EventBus) {
+	l.events = events
+	events.OnDrop(l, l.OnDropPlanet)
+	events.OnRelease(l, l.OnReleasePlanet)
+}
+
+// Makes the launcher follow the DropPlanet and ReleasePlanet events a bot
+// sends with ApplyActions.
+func (l *Launcher) ListenForBot(events *EventBus) {
+	l.bot = true
+	l.Listen(events)
+}
+
+func (l *Launcher) Delete() {
+	if l.events != nil {
+		l.events.Release(l)
+	}
+}
+
+// Starts a drag at pt.
+func (l *Launcher) Drop(pt twodee.Point) {
+	l.Phantom = l.sim.DropPlanet(pt)
+}
+
+// Ends the drag at pt, launching the planet if one is being dragged.
+func (l *Launcher) Release(pt twodee.Point) {
+	if l.Phantom != nil {
+		l.sim.ReleasePlanet(l.Phantom, pt)
+		l.Phantom = nil
+	}
+}
+
+func (l *Launcher) OnDropPlanet(event *DropPlanetEvent) {
+	if event.Bot == l.bot {
+		l.Drop(twodee.Pt(event.X, event.Y))
+	}
+}
+
+func (l *Launcher) OnReleasePlanet(event *ReleasePlanetEvent) {
+	if event.Bot == l.bot {
+		l.Release(twodee.Pt(event.X, event.Y))
+	}
+}
+
+// Drops the planet being dragged without launching it.
+func (l *Launcher) Cancel() {
+	l.Phantom = nil
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+
+	twodee "../libs/twodee"
+)
+
+func TestLauncherBotActionsAreEvents(t *testing.T) {
+	var (
+		events   = NewEventBus(false)
+		sim      = NewSimulation(FieldBounds(1), events, 1)
+		mouse    = NewLauncher(sim)
+		bot      = NewLauncher(sim)
+		released = 0
+	)
+	mouse.Listen(events)
+	bot.ListenForBot(events)
+	events.OnRelease(t, func(e *ReleasePlanetEvent) {
+		if e.Bot {
+			released++
+		}
+	})
+	events.Enqueue(NewDropPlanetEvent(-20, 0))
+	ApplyActions(events, []PlayerAction{Drop(twodee.Pt(20, 0))})
+	events.Poll()
+	if mouse.Phantom == nil || bot.Phantom == nil || mouse.Phantom == bot.Phantom {
+		t.Fatalf("mouse and bot drags got mixed up")
+	}
+	ApplyActions(events, []PlayerAction{Release(twodee.Pt(20, 1))})
+	events.Poll()
+	if released != 1 {
+		t.Errorf("bot released %v planets, want 1", released)
+	}
+	if bot.Phantom != nil || mouse.Phantom == nil {
+		t.Errorf("bot release went to the wrong launcher")
+	}
+	if len(sim.Planets) != 1 || sim.Planets[0].Pos().X != 20 {
+		t.Errorf("got planets %v, want the bot's at x=20", sim.Planets)
+	}
+}
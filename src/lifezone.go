@@ -0,0 +1,35 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+)
+
+const (
+	lifeZoneSegments = 96
+	// How far beyond the life zone the cold tint fades out.
+	coldFadeDist = 12.0
+)
+
+var (
+	tooHotColor    = color.RGBA{255, 90, 40, 45}
+	habitableColor = color.RGBA{120, 255, 120, 20}
+	tooColdColor   = color.RGBA{90, 160, 255, 45}
+	clearColor     = color.RGBA{90, 160, 255, 0}
+	lifeEdgeColor  = color.RGBA{120, 255, 120, 90}
+)
+
+// Shades the regions around the sun where planets burn, thrive and freeze.
+func (l *GameLayer) drawLifeZone() {
+	var (
+		sun    = l.Sim.Sun
+		center = sun.Pos()
+	)
+	l.ShapeRenderer.DrawTriangleStrip(AnnulusVertices(center, sun.Radius, TooCloseDist, lifeZoneSegments, tooHotColor, tooHotColor))
+	l.ShapeRenderer.DrawTriangleStrip(AnnulusVertices(center, TooCloseDist, TooFarDist, lifeZoneSegments, habitableColor, habitableColor))
+	l.ShapeRenderer.DrawTriangleStrip(AnnulusVertices(center, TooFarDist, TooFarDist+coldFadeDist, lifeZoneSegments, tooColdColor, clearColor))
+	l.ShapeRenderer.DrawLineLoop(CircleVertices(center, TooCloseDist, lifeZoneSegments, lifeEdgeColor))
+	l.ShapeRenderer.DrawLineLoop(CircleVertices(center, TooFarDist, lifeZoneSegments, lifeEdgeColor))
+}
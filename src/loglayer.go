@@ -0,0 +1,186 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	logPanelWidth   = 480
+	logLineHeight   = 22
+	logPanelPadding = 10
+	// Entries moved per page key or scroll wheel notch.
+	logPageStep   = 5
+	logScrollStep = 3
+)
+
+var logBackgroundColor = color.RGBA{0, 0, 0, 170}
+
+// LogLayer shows the game's event log down the right side of the screen.
+type LogLayer struct {
+	game       *GameLayer
+	text       *twodee.TextRenderer
+	shapes     *ShapeRenderer
+	font       *twodee.FontFace
+	titleFont  *twodee.FontFace
+	titleCache *twodee.TextCache
+	lineCache  map[int]*twodee.TextCache
+	bounds     twodee.Rectangle
+	visible    bool
+	// Number of the newest entries scrolled off the bottom of the panel.
+	scroll int
+}
+
+func NewLogLayer(app *Application, game *GameLayer) (layer *LogLayer, err error) {
+	var (
+		font      *twodee.FontFace
+		titleFont *twodee.FontFace
+		bg        = color.Transparent
+		exoFont   = "assets/fonts/Exo-SemiBold.ttf"
+	)
+	if font, err = twodee.NewFontFace(exoFont, 18, regColor, bg); err != nil {
+		return
+	}
+	if titleFont, err = twodee.NewFontFace(exoFont, 24, hiColor, bg); err != nil {
+		return
+	}
+	layer = &LogLayer{
+		game:       game,
+		font:       font,
+		titleFont:  titleFont,
+		titleCache: twodee.NewTextCache(titleFont),
+		lineCache:  map[int]*twodee.TextCache{},
+		bounds:     app.WinBounds,
+		visible:    false,
+		scroll:     0,
+	}
+	layer.titleCache.SetText("EVENT LOG")
+	err = layer.Reset()
+	return
+}
+
+func (l *LogLayer) Delete() {
+	if l.text != nil {
+		l.text.Delete()
+	}
+	if l.shapes != nil {
+		l.shapes.Delete()
+	}
+	for _, v := range l.lineCache {
+		v.Delete()
+	}
+	l.lineCache = map[int]*twodee.TextCache{}
+}
+
+// Returns how many entries fit in the panel.
+func (l *LogLayer) rows() int {
+	var height = l.bounds.Max.Y - l.bounds.Min.Y - 2*logPanelPadding - logLineHeight*2
+	return int(height / logLineHeight)
+}
+
+func (l *LogLayer) Scroll(entries int) {
+	var limit = len(l.game.Log.Entries) - l.rows()
+	l.scroll += entries
+	if l.scroll > limit {
+		l.scroll = limit
+	}
+	if l.scroll < 0 {
+		l.scroll = 0
+	}
+}
+
+func (l *LogLayer) Render() {
+	if !l.visible {
+		return
+	}
+	var (
+		entries   = l.game.Log.Entries
+		end       = len(entries) - l.scroll
+		start     = end - l.rows()
+		x         = l.bounds.Max.X - logPanelWidth + logPanelPadding
+		y         = l.bounds.Max.Y - logPanelPadding
+		textCache *twodee.TextCache
+		ok        bool
+	)
+	if start < 0 {
+		start = 0
+	}
+	l.shapes.Bind()
+	l.shapes.DrawTriangles(RectangleVertices(twodee.Rect(
+		l.bounds.Max.X-logPanelWidth,
+		l.bounds.Min.Y,
+		l.bounds.Max.X,
+		l.bounds.Max.Y,
+	), logBackgroundColor))
+	l.shapes.Unbind()
+
+	l.text.Bind()
+	if l.titleCache.Texture != nil {
+		y -= float32(l.titleCache.Texture.Height)
+		l.text.Draw(l.titleCache.Texture, x, y)
+	}
+	y -= logLineHeight
+	for i := start; i < end; i++ {
+		var row = i - start
+		if textCache, ok = l.lineCache[row]; !ok {
+			textCache = twodee.NewTextCache(l.font)
+			l.lineCache[row] = textCache
+		}
+		textCache.SetText(entries[i].String())
+		y -= logLineHeight
+		if textCache.Texture != nil {
+			l.text.Draw(textCache.Texture, x, y)
+		}
+	}
+	l.text.Unbind()
+}
+
+func (l *LogLayer) HandleEvent(evt twodee.Event) bool {
+	switch event := evt.(type) {
+	case *twodee.KeyEvent:
+		if event.Type != twodee.Press || l.game.Renaming != nil {
+			break
+		}
+		if l.game.App.Keys.Is(ActionLog, event.Code) {
+			l.visible = !l.visible
+			l.scroll = 0
+			return false
+		}
+		if !l.visible {
+			break
+		}
+		switch {
+		case l.game.App.Keys.Is(ActionLogUp, event.Code):
+			l.Scroll(logPageStep)
+			return false
+		case l.game.App.Keys.Is(ActionLogDown, event.Code):
+			l.Scroll(-logPageStep)
+			return false
+		}
+	case *MouseScrollEvent:
+		if l.visible {
+			l.Scroll(int(event.Y) * logScrollStep)
+			return false
+		}
+	}
+	return true
+}
+
+func (l *LogLayer) Update(elapsed time.Duration) {
+}
+
+func (l *LogLayer) Reset() (err error) {
+	l.Delete()
+	if l.text, err = twodee.NewTextRenderer(l.bounds); err != nil {
+		return
+	}
+	if l.shapes, err = NewShapeRenderer(l.bounds); err != nil {
+		return
+	}
+	return
+}
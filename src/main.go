@@ -1,12 +1,20 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import (
+	"flag"
+	"fmt"
+	"log"
 	"math/rand"
+	"os"
 	"runtime"
 	"time"
 
 	twodee "../libs/twodee"
 	"github.com/go-gl/gl"
+	glfw "github.com/go-gl/glfw3"
 )
 
 func init() {
@@ -15,26 +23,42 @@ func init() {
 }
 
 type Application struct {
-	layers                *twodee.Layers
-	Context               *twodee.Context
-	AudioSystem           *AudioSystem
-	WinBounds             twodee.Rectangle
-	GameEventHandler      *twodee.GameEventHandler
-	gameClosingObserverId int
-	InitiateCloseGame     bool
+	layers      *twodee.Layers
+	Context     *twodee.Context
+	AudioSystem *AudioSystem
+	Profile     *Profile
+	HighScores  *HighScores
+	Keys        *KeyBindings
+	WinBounds   twodee.Rectangle
+	Events      *EventBus
+	State       *GameStateMachine
+	Trace       *TraceSink // Nil unless the game is being traced.
+	Seed        int64
+	Ranked      bool    // Ranked games are played without rewinding.
+	FieldScale  float32 // Size of the playing field relative to the default view.
+	Debug       bool    // Show diagnostics and log every game event.
+	Bot         string  // Name of a bot to play alongside, if any.
+}
+
+// Scroll wheel input, which twodee does not report itself.
+type MouseScrollEvent struct {
+	X float32
+	Y float32
 }
 
-func NewApplication() (app *Application, err error) {
+func NewApplication(seed int64, ranked bool, fieldScale float32, debug bool, bot string) (app *Application, err error) {
 	var (
-		layers            *twodee.Layers
-		context           *twodee.Context
-		gameLayer         *GameLayer
-		hudLayer          *HudLayer
-		menuLayer         *MenuLayer
-		overlayLayer      *OverlayLayer
-		winbounds         = twodee.Rect(0, 0, 1024, 768)
-		gameEventHandler  = twodee.NewGameEventHandler(NumGameEventTypes)
-		initiateCloseGame = false
+		layers       *twodee.Layers
+		context      *twodee.Context
+		gameLayer    *GameLayer
+		hudLayer     *HudLayer
+		logLayer     *LogLayer
+		menuLayer    *MenuLayer
+		overlayLayer *OverlayLayer
+		panelLayer   *PanelLayer
+		titleLayer   *TitleLayer
+		winbounds    = twodee.Rect(0, 0, 1024, 768)
+		events       = NewEventBus(debug)
 	)
 	if context, err = twodee.NewContext(); err != nil {
 		return
@@ -50,11 +74,32 @@ func NewApplication() (app *Application, err error) {
 	winbounds.Max.Y = float32(height)
 	layers = twodee.NewLayers()
 	app = &Application{
-		layers:            layers,
-		Context:           context,
-		WinBounds:         winbounds,
-		GameEventHandler:  gameEventHandler,
-		InitiateCloseGame: initiateCloseGame,
+		layers:     layers,
+		Context:    context,
+		WinBounds:  winbounds,
+		Events:     events,
+		State:      NewGameStateMachine(events, StateTitle),
+		Seed:       seed,
+		Ranked:     ranked,
+		FieldScale: fieldScale,
+		Debug:      debug,
+		Bot:        bot,
+	}
+	context.Window.SetScrollCallback(app.OnScroll)
+	if app.Profile, err = LoadProfile(); err != nil {
+		log.Printf("Could not load profile, starting a new one: %v", err)
+		err = nil
+	}
+	if app.HighScores, err = LoadHighScores(); err != nil {
+		log.Printf("Could not load high scores, starting a new table: %v", err)
+		err = nil
+	}
+	if app.Keys, err = LoadKeyBindings(); err != nil {
+		log.Printf("Could not load controls, using the defaults: %v", err)
+		err = nil
+	}
+	for _, conflict := range app.Keys.Conflicts() {
+		log.Printf("%v and %v are both bound to %v", conflict.Already, conflict.Action, KeyName(conflict.Key))
 	}
 	if gameLayer, err = NewGameLayer(app); err != nil {
 		return
@@ -62,22 +107,34 @@ func NewApplication() (app *Application, err error) {
 	if hudLayer, err = NewHudLayer(app, gameLayer); err != nil {
 		return
 	}
-	if menuLayer, err = NewMenuLayer(app, twodee.Pt(256, 190)); err != nil {
+	if logLayer, err = NewLogLayer(app, gameLayer); err != nil {
+		return
+	}
+	if menuLayer, err = NewMenuLayer(app, gameLayer, twodee.Pt(256, 190)); err != nil {
 		return
 	}
 	if overlayLayer, err = NewOverlayLayer(app, gameLayer); err != nil {
 		return
 	}
+	if panelLayer, err = NewPanelLayer(app, twodee.Pt(200, 120)); err != nil {
+		return
+	}
+	if titleLayer, err = NewTitleLayer(app, gameLayer); err != nil {
+		return
+	}
 	if app.AudioSystem, err = NewAudioSystem(app); err != nil {
 		return
 	}
 
 	layers.Push(gameLayer)
 	layers.Push(hudLayer)
+	layers.Push(logLayer)
 	layers.Push(menuLayer)
 	layers.Push(overlayLayer)
-	app.gameClosingObserverId = app.GameEventHandler.AddObserver(GameIsClosing, app.CloseGame)
-	app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(PlayBackgroundMusic))
+	layers.Push(panelLayer)
+	layers.Push(titleLayer)
+	app.Events.Subscribe(app, GameIsClosing, app.CloseGame)
+	app.Events.Enqueue(twodee.NewBasicGameEvent(PlayBackgroundMusic))
 	return
 }
 
@@ -91,7 +148,10 @@ func (a *Application) Update(elapsed time.Duration) {
 }
 
 func (a *Application) Delete() {
-	a.GameEventHandler.RemoveObserver(GameIsClosing, a.gameClosingObserverId)
+	a.Events.Release(a)
+	if a.Trace != nil {
+		a.Trace.Delete()
+	}
 	a.layers.Delete()
 	a.AudioSystem.Delete()
 	a.Context.Delete()
@@ -113,22 +173,80 @@ func (a *Application) ProcessEvents() {
 	}
 }
 
+func (a *Application) OnScroll(w *glfw.Window, xoff, yoff float64) {
+	a.layers.HandleEvent(&MouseScrollEvent{float32(xoff), float32(yoff)})
+}
+
 func (a *Application) CloseGame(e twodee.GETyper) {
-	a.InitiateCloseGame = true
+	a.State.Goto(StateClosing)
+}
+
+// Ends the game in progress, if there is one.
+func (a *Application) EndGame() {
+	if a.State.Goto(StateGameOver) == nil {
+		a.Events.Enqueue(twodee.NewBasicGameEvent(GameOver))
+	}
 }
 
 func main() {
-	rand.Seed(int64(time.Now().Nanosecond()))
+	var (
+		ranked     = flag.Bool("ranked", false, "play a ranked game, with rewinding disabled")
+		fieldScale = flag.Float64("field", 1.0, "size of the playing field relative to the screen")
+		debug      = flag.Bool("debug", false, "show diagnostics and log game events")
+		trace      = flag.String("trace", "", "write every game event to this file as JSON")
+		bot        = flag.String("bot", "", fmt.Sprintf("play alongside a bot, one of %v", BotNames()))
+	)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: %v [flags]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %v summarize TRACE...\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var command func([]string) error
+	switch flag.Arg(0) {
+	case "summarize":
+		command = summarizeTraces
+	}
+	if command != nil {
+		if err := command(flag.Args()[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	var seed = int64(time.Now().Nanosecond())
+	rand.Seed(seed)
 
 	var (
-		app *Application
-		err error
+		app   *Application
+		scale float32
+		err   error
 	)
+	if scale, err = CheckFieldScale(*fieldScale); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if *bot != "" {
+		if _, err = NewBot(*bot, 0); err == nil && *ranked {
+			err = fmt.Errorf("Ranked games cannot be played with a bot")
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
 
-	if app, err = NewApplication(); err != nil {
+	if app, err = NewApplication(seed, *ranked, scale, *debug, *bot); err != nil {
 		panic(err)
 	}
 	defer app.Delete()
+	if *trace != "" {
+		if app.Trace, err = NewTraceSink(*trace, app.Events); err != nil {
+			panic(err)
+		}
+	}
 
 	var (
 		last_render  = time.Now()
@@ -137,7 +255,7 @@ func main() {
 		step         = twodee.Step60Hz
 		render_max   = step
 	)
-	for !app.Context.Window.ShouldClose() && !app.InitiateCloseGame {
+	for !app.Context.Window.ShouldClose() && !app.State.Is(StateClosing) {
 		for !updated_to.After(current_time) {
 			app.Update(step)
 			updated_to = updated_to.Add(step)
@@ -150,7 +268,7 @@ func main() {
 			app.Context.Window.SwapBuffers()
 			last_render = current_time
 			app.Context.Events.Poll()
-			app.GameEventHandler.Poll()
+			app.Events.Poll()
 			app.ProcessEvents()
 		}
 		current_time = time.Now()
@@ -1,7 +1,12 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import (
+	"fmt"
 	"image/color"
+	"log"
 	"time"
 
 	twodee "../libs/twodee"
@@ -13,10 +18,14 @@ const (
 	exitCode
 	musicCode
 	gameOverCode
+	profileCode
+	highScoresCode
+	controlsCode
+	resetControlsCode
 )
 
 type MenuLayer struct {
-	visible  bool
+	obscured bool
 	menu     *twodee.Menu
 	text     *twodee.TextRenderer
 	regFont  *twodee.FontFace
@@ -28,9 +37,15 @@ type MenuLayer struct {
 	bounds   twodee.Rectangle
 	offset   twodee.Point
 	app      *Application
+	game     *GameLayer
+	// Items that show the keys bound to an action.
+	bindings map[twodee.MenuItem]InputAction
+	// Set while waiting for the key to bind to rebinding.
+	capturing bool
+	rebinding InputAction
 }
 
-func NewMenuLayer(app *Application, offset twodee.Point) (layer *MenuLayer, err error) {
+func NewMenuLayer(app *Application, game *GameLayer, offset twodee.Point) (layer *MenuLayer, err error) {
 	var (
 		menu    *twodee.Menu
 		text    *twodee.TextRenderer
@@ -52,8 +67,12 @@ func NewMenuLayer(app *Application, offset twodee.Point) (layer *MenuLayer, err
 	if text, err = twodee.NewTextRenderer(app.WinBounds); err != nil {
 		return
 	}
+	var controls, bindings = newControlsMenuItem()
 	menu, err = twodee.NewMenu([]twodee.MenuItem{
 		twodee.NewKeyValueMenuItem("Music On/Off", programCode, musicCode),
+		controls,
+		twodee.NewKeyValueMenuItem("Profile", programCode, profileCode),
+		twodee.NewKeyValueMenuItem("High Scores", programCode, highScoresCode),
 		twodee.NewKeyValueMenuItem("Exit", programCode, exitCode),
 		// TODO: REMOVE.
 		twodee.NewKeyValueMenuItem("Game Over", programCode, gameOverCode),
@@ -62,7 +81,7 @@ func NewMenuLayer(app *Application, offset twodee.Point) (layer *MenuLayer, err
 		return
 	}
 	layer = &MenuLayer{
-		visible:  false,
+		obscured: false,
 		menu:     menu,
 		text:     text,
 		regFont:  regFont,
@@ -74,32 +93,82 @@ func NewMenuLayer(app *Application, offset twodee.Point) (layer *MenuLayer, err
 		bounds:   app.WinBounds,
 		offset:   offset,
 		app:      app,
+		game:     game,
+		bindings: bindings,
 	}
+	// Panels shown on top of the menu take its input.
+	app.Events.Subscribe(layer, ShowPanel, layer.OnShowPanel)
+	app.Events.Subscribe(layer, HidePanel, layer.OnHidePanel)
 	return
 
 }
 
+// Returns a submenu for rebinding keys, with an item for each action
+// grouped by section, and the action each item rebinds.
+func newControlsMenuItem() (item twodee.MenuItem, bindings map[twodee.MenuItem]InputAction) {
+	var sections = []twodee.MenuItem{}
+	bindings = map[twodee.MenuItem]InputAction{}
+	for section, label := range controlSectionLabels {
+		var items = []twodee.MenuItem{}
+		for i, info := range inputActions {
+			if info.section != section {
+				continue
+			}
+			var item = twodee.NewKeyValueMenuItem(info.label, controlsCode, int32(i))
+			bindings[item] = InputAction(i)
+			items = append(items, item)
+		}
+		items = append(items, twodee.NewBackMenuItem("Back"))
+		sections = append(sections, twodee.NewParentMenuItem(label, items))
+	}
+	sections = append(sections,
+		twodee.NewKeyValueMenuItem("Reset Controls", programCode, resetControlsCode),
+		twodee.NewBackMenuItem("Back"),
+	)
+	return twodee.NewParentMenuItem("Controls", sections), bindings
+}
+
+func (l *MenuLayer) OnShowPanel(e twodee.GETyper) {
+	l.obscured = true
+}
+
+func (l *MenuLayer) OnHidePanel(e twodee.GETyper) {
+	l.obscured = false
+}
+
 func (l *MenuLayer) HandleEvent(evt twodee.Event) bool {
-	// Handle the !visible case quickly.
-	if !l.visible {
+	// Handle the closed case quickly.
+	if !l.app.State.Is(StateMenu) {
+		// The rename box takes every key, including the menu key.
+		if l.game.Renaming != nil {
+			return true
+		}
 		switch event := evt.(type) {
 		case *twodee.KeyEvent:
 			if event.Type != twodee.Press {
 				break
 			}
-			if event.Code == twodee.KeyEscape {
-				if l.visible {
-					l.visible = false
-					return false
-				}
+			if l.app.Keys.Is(ActionMenu, event.Code) && l.app.State.Goto(StateMenu) == nil {
 				l.menu.Reset()
-				l.visible = true
-				l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuOpen))
 				return false
 			}
 		}
 		return true
 	}
+	if l.capturing {
+		switch event := evt.(type) {
+		case *twodee.KeyEvent:
+			if event.Type == twodee.Press {
+				l.rebind(event.Code)
+			}
+		case *twodee.MouseButtonEvent:
+			// A click always cancels, even when rebinding the menu key.
+			if event.Type == twodee.Press {
+				l.capturing = false
+			}
+		}
+		return false
+	}
 	switch event := evt.(type) {
 	case *twodee.MouseButtonEvent:
 		if event.Type != twodee.Press {
@@ -108,7 +177,7 @@ func (l *MenuLayer) HandleEvent(evt twodee.Event) bool {
 		if data := l.menu.Select(); data != nil {
 			l.handleMenuItem(data)
 		}
-		l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuSel))
+		l.app.Events.Enqueue(twodee.NewBasicGameEvent(MenuSel))
 		return false
 	case *twodee.MouseMoveEvent:
 		var (
@@ -133,7 +202,7 @@ func (l *MenuLayer) HandleEvent(evt twodee.Event) bool {
 				by = y + float32(texture.Height)
 				if cy >= y && cy <= by {
 					if !item.Highlighted() {
-						l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuClick))
+						l.app.Events.Enqueue(twodee.NewBasicGameEvent(MenuClick))
 						l.menu.HighlightItem(item)
 					}
 					break
@@ -145,24 +214,27 @@ func (l *MenuLayer) HandleEvent(evt twodee.Event) bool {
 		if event.Type != twodee.Press {
 			break
 		}
-		switch event.Code {
-		case twodee.KeyEscape:
-			l.visible = false
-			l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuClose))
+		var action, ok = l.app.Keys.Action(event.Code, ContextMenu)
+		if !ok {
+			break
+		}
+		switch action {
+		case ActionMenu:
+			l.app.State.Back()
 			return false
-		case twodee.KeyUp:
+		case ActionMenuUp:
 			l.menu.Prev()
-			l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuClick))
+			l.app.Events.Enqueue(twodee.NewBasicGameEvent(MenuClick))
 			return false
-		case twodee.KeyDown:
+		case ActionMenuDown:
 			l.menu.Next()
-			l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuClick))
+			l.app.Events.Enqueue(twodee.NewBasicGameEvent(MenuClick))
 			return false
-		case twodee.KeyEnter:
+		case ActionConfirm:
 			if data := l.menu.Select(); data != nil {
 				l.handleMenuItem(data)
 			}
-			l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuSel))
+			l.app.Events.Enqueue(twodee.NewBasicGameEvent(MenuSel))
 			return false
 		}
 	}
@@ -176,19 +248,64 @@ func (l *MenuLayer) handleMenuItem(data *twodee.MenuItemData) {
 		case musicCode:
 			// TODO: Write code that mutes/un-mutes music.
 			if twodee.MusicIsPaused() {
-				l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(ResumeMusic))
+				l.app.Events.Enqueue(twodee.NewBasicGameEvent(ResumeMusic))
 			} else {
-				l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(PauseMusic))
+				l.app.Events.Enqueue(twodee.NewBasicGameEvent(PauseMusic))
 
 			}
+		case profileCode:
+			l.app.Events.Enqueue(NewPanelEvent("PROFILE", l.app.Profile.Summary()))
+		case highScoresCode:
+			l.app.Events.Enqueue(NewPanelEvent("HIGH SCORES", l.app.HighScores.Lines(highScoresMax)))
+		case resetControlsCode:
+			l.app.Keys.Reset()
+			l.saveKeys()
+			l.app.Events.Enqueue(NewMessageEvent("CONTROLS RESET"))
 		case exitCode:
-			l.app.InitiateCloseGame = true
+			l.app.State.Goto(StateClosing)
 		case gameOverCode:
-			l.visible = false
-			l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(MenuClose))
-			l.app.GameEventHandler.Enqueue(twodee.NewBasicGameEvent(GameOver))
+			// Does nothing more than close the menu if the game is
+			// already over.
+			l.app.State.Back()
+			l.app.EndGame()
 		}
+	case controlsCode:
+		l.capturing = true
+		l.rebinding = InputAction(data.Value)
+	}
+}
+
+// Binds the action being rebound to code. The menu key cancels, unless it
+// is the menu key being rebound; a click cancels either way.
+func (l *MenuLayer) rebind(code twodee.KeyCode) {
+	l.capturing = false
+	if l.app.Keys.Is(ActionMenu, code) && l.rebinding != ActionMenu {
+		return
+	}
+	if err := l.app.Keys.Bind(l.rebinding, code); err != nil {
+		l.app.Events.Enqueue(NewMessageEvent(err.Error()))
+		return
+	}
+	l.saveKeys()
+}
+
+func (l *MenuLayer) saveKeys() {
+	if err := l.app.Keys.Save(); err != nil {
+		log.Printf("Could not save controls: %v", err)
+	}
+}
+
+// Returns the text shown for item, with the keys bound to its action if it
+// has one.
+func (l *MenuLayer) itemLabel(item twodee.MenuItem) string {
+	var action, ok = l.bindings[item]
+	switch {
+	case !ok:
+		return item.Label()
+	case l.capturing && action == l.rebinding:
+		return fmt.Sprintf("%v: PRESS A KEY OR CLICK TO CANCEL", item.Label())
 	}
+	return fmt.Sprintf("%v: %v", item.Label(), l.app.Keys.Describe(action))
 }
 
 func (l *MenuLayer) Update(elapsed time.Duration) {}
@@ -209,6 +326,7 @@ func (l *MenuLayer) Reset() (err error) {
 }
 
 func (l *MenuLayer) Delete() {
+	l.app.Events.Release(l)
 	l.text.Delete()
 	l.actCache.Delete()
 	l.hiCache.Delete()
@@ -218,7 +336,7 @@ func (l *MenuLayer) Delete() {
 }
 
 func (l *MenuLayer) Render() {
-	if !l.visible {
+	if !l.app.State.Is(StateMenu) || l.obscured {
 		return
 	}
 	var (
@@ -231,17 +349,17 @@ func (l *MenuLayer) Render() {
 	l.text.Bind()
 	for i, item := range l.menu.Items() {
 		if item.Highlighted() {
-			l.hiCache.SetText(item.Label())
+			l.hiCache.SetText(l.itemLabel(item))
 			texture = l.hiCache.Texture
 		} else if item.Active() {
-			l.actCache.SetText(item.Label())
+			l.actCache.SetText(l.itemLabel(item))
 			texture = l.actCache.Texture
 		} else {
 			if textCache, ok = l.cache[i]; !ok {
 				textCache = twodee.NewTextCache(l.regFont)
 				l.cache[i] = textCache
 			}
-			textCache.SetText(item.Label())
+			textCache.SetText(l.itemLabel(item))
 			texture = textCache.Texture
 		}
 		if texture != nil {
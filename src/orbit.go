@@ -0,0 +1,149 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	TrailLength   = 60
+	TrailInterval = 100 * time.Millisecond
+	// Segments used to draw a predicted orbit.
+	orbitSegments = 96
+)
+
+var (
+	trailColor         = color.RGBA{200, 200, 255, 150}
+	stableOrbitColor   = color.RGBA{120, 255, 120, 160}
+	unstableOrbitColor = color.RGBA{255, 240, 120, 160}
+	doomedOrbitColor   = color.RGBA{255, 80, 80, 160}
+)
+
+// Trail remembers a planet's recent positions, oldest first.
+type Trail struct {
+	points  []twodee.Point
+	elapsed time.Duration
+}
+
+func NewTrail() *Trail {
+	return &Trail{
+		points:  []twodee.Point{},
+		elapsed: TrailInterval,
+	}
+}
+
+func (t *Trail) Update(elapsed time.Duration, pos twodee.Point) {
+	t.elapsed += elapsed
+	if t.elapsed < TrailInterval {
+		return
+	}
+	t.elapsed = 0
+	t.points = append(t.points, pos)
+	if len(t.points) > TrailLength {
+		t.points = t.points[1:]
+	}
+}
+
+// Returns the trail, fading out toward its oldest end.
+func (t *Trail) Vertices(current twodee.Point) []ShapeVertex {
+	var (
+		vertices = make([]ShapeVertex, len(t.points)+1)
+		c        = trailColor
+	)
+	for i, pt := range t.points {
+		c.A = uint8(int(trailColor.A) * i / len(t.points))
+		vertices[i] = ShapeVertex{pt, c}
+	}
+	vertices[len(t.points)] = ShapeVertex{current, trailColor}
+	return vertices
+}
+
+// Orbit is the osculating Kepler orbit of a planet around the sun, ignoring
+// every other planet.
+type Orbit struct {
+	Bound        bool
+	Eccentricity float64
+	SemiMajor    float64
+	Periapsis    float64
+	Apoapsis     float64
+	center       twodee.Point
+	axis         twodee.Point
+	semiMinor    float64
+}
+
+func PredictOrbit(p, sun *PlanetaryBody) (o Orbit) {
+	var (
+		mu     = GravConst * float64(sun.Mass)
+		rx     = float64(p.Pos().X - sun.Pos().X)
+		ry     = float64(p.Pos().Y - sun.Pos().Y)
+		vx     = float64(p.Velocity.X)
+		vy     = float64(p.Velocity.Y)
+		r      = math.Hypot(rx, ry)
+		v2     = vx*vx + vy*vy
+		energy = v2/2 - mu/r
+		rv     = rx*vx + ry*vy
+		// Eccentricity vector, pointing at periapsis.
+		ex = ((v2-mu/r)*rx - rv*vx) / mu
+		ey = ((v2-mu/r)*ry - rv*vy) / mu
+	)
+	o.Eccentricity = math.Hypot(ex, ey)
+	if energy >= 0 || o.Eccentricity >= 1 {
+		o.Bound = false
+		return
+	}
+	o.Bound = true
+	o.SemiMajor = -mu / (2 * energy)
+	o.Periapsis = o.SemiMajor * (1 - o.Eccentricity)
+	o.Apoapsis = o.SemiMajor * (1 + o.Eccentricity)
+	o.semiMinor = o.SemiMajor * math.Sqrt(1-o.Eccentricity*o.Eccentricity)
+	if o.Eccentricity > 0 {
+		o.axis = twodee.Pt(float32(ex/o.Eccentricity), float32(ey/o.Eccentricity))
+	} else {
+		o.axis = twodee.Pt(1, 0)
+	}
+	// The sun sits at a focus, so the centre is offset away from periapsis.
+	o.center = sun.Pos().Sub(o.axis.Scale(float32(o.SemiMajor * o.Eccentricity)))
+	return
+}
+
+// Stable orbits stay inside the life zone, doomed ones escape or hit the sun.
+func (o Orbit) Color(p, sun *PlanetaryBody) color.RGBA {
+	switch {
+	case !o.Bound || o.Periapsis < float64(sun.Radius+p.Radius):
+		return doomedOrbitColor
+	case o.Periapsis >= TooCloseDist && o.Apoapsis <= TooFarDist:
+		return stableOrbitColor
+	default:
+		return unstableOrbitColor
+	}
+}
+
+// Returns the point of a bound orbit furthest from the sun.
+func (o Orbit) ApoapsisPos(sun *PlanetaryBody) twodee.Point {
+	return sun.Pos().Sub(o.axis.Scale(float32(o.Apoapsis)))
+}
+
+func (o Orbit) Vertices(c color.RGBA) []ShapeVertex {
+	if !o.Bound {
+		return []ShapeVertex{}
+	}
+	var (
+		vertices = make([]ShapeVertex, orbitSegments)
+		normal   = twodee.Pt(-o.axis.Y, o.axis.X)
+	)
+	for i := 0; i < orbitSegments; i++ {
+		var (
+			a = 2 * math.Pi * float64(i) / orbitSegments
+			x = float32(o.SemiMajor * math.Cos(a))
+			y = float32(o.semiMinor * math.Sin(a))
+		)
+		vertices[i] = ShapeVertex{
+			o.center.Add(o.axis.Scale(x)).Add(normal.Scale(y)),
+			c,
+		}
+	}
+	return vertices
+}
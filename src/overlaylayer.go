@@ -1,8 +1,13 @@
+//go:build !batch
+// +build !batch
+
 package main
 
 import (
 	"fmt"
 	"image/color"
+	"log"
+	"strings"
 	"time"
 
 	"../libs/twodee"
@@ -10,24 +15,33 @@ import (
 
 const (
 	overlayEndFrame = 0
+	// Number of high scores listed on the end screen.
+	overlayHighScores = 5
+	maxNameLength     = 12
+	// Distance from the score breakdown to the list of cheevos beside it.
+	overlayCheevoColumn = 360
 )
 
 type OverlayLayer struct {
-	game             *GameLayer
-	app              *Application
-	events           *twodee.GameEventHandler
-	tileRenderer     *twodee.TileRenderer
-	bounds           twodee.Rectangle
-	tileM            twodee.TileMetadata
-	text             *twodee.TextRenderer
-	regFont          *twodee.FontFace
-	offset           twodee.Point
-	popFont          *twodee.FontFace
-	maxPopCache      *twodee.TextCache
-	cheevosCache     map[int]*twodee.TextCache
-	visible          bool
-	frame            int
-	gameOverObserver int
+	game         *GameLayer
+	app          *Application
+	events       *EventBus
+	tileRenderer *twodee.TileRenderer
+	shapes       *ShapeRenderer
+	bounds       twodee.Rectangle
+	tileM        twodee.TileMetadata
+	text         *twodee.TextRenderer
+	regFont      *twodee.FontFace
+	offset       twodee.Point
+	popFont      *twodee.FontFace
+	maxPopCache  *twodee.TextCache
+	scoreCache   map[int]*twodee.TextCache
+	cheevosCache map[int]*twodee.TextCache
+	nameCache    *twodee.TextCache
+	scoresCache  map[int]*twodee.TextCache
+	nameInput    *TextInput
+	submitted    bool
+	frame        int
 }
 
 func NewOverlayLayer(app *Application, game *GameLayer) (layer *OverlayLayer, err error) {
@@ -55,14 +69,18 @@ func NewOverlayLayer(app *Application, game *GameLayer) (layer *OverlayLayer, er
 	layer = &OverlayLayer{
 		app:          app,
 		game:         game,
-		events:       app.GameEventHandler,
+		events:       app.Events,
 		bounds:       app.WinBounds,
 		regFont:      regFont,
 		popFont:      popFont,
 		offset:       twodee.Pt(200, 260),
+		scoreCache:   map[int]*twodee.TextCache{},
 		cheevosCache: map[int]*twodee.TextCache{},
 		maxPopCache:  twodee.NewTextCache(popFont),
-		visible:      false,
+		nameCache:    twodee.NewTextCache(popFont),
+		scoresCache:  map[int]*twodee.TextCache{},
+		nameInput:    NewTextInput(maxNameLength),
+		submitted:    false,
 		frame:        overlayEndFrame,
 		tileM:        tileM,
 	}
@@ -70,24 +88,29 @@ func NewOverlayLayer(app *Application, game *GameLayer) (layer *OverlayLayer, er
 	return
 }
 
-func (l *OverlayLayer) OnGameOver(e twodee.GETyper) {
-	l.visible = true
-}
-
 func (l *OverlayLayer) Delete() {
-	l.visible = false
 	if l.tileRenderer != nil {
 		l.tileRenderer.Delete()
 	}
+	if l.shapes != nil {
+		l.shapes.Delete()
+	}
 	l.maxPopCache.Delete()
+	l.nameCache.Delete()
+	for _, v := range l.scoresCache {
+		v.Delete()
+	}
+	for _, v := range l.scoreCache {
+		v.Delete()
+	}
 	for _, v := range l.cheevosCache {
-		v.Clear()
+		v.Delete()
 	}
-	l.events.RemoveObserver(GameOver, l.gameOverObserver)
+	l.events.Release(l)
 }
 
 func (l *OverlayLayer) Render() {
-	if !l.visible {
+	if !l.app.State.Is(StateGameOver) {
 		return
 	}
 	var (
@@ -101,14 +124,18 @@ func (l *OverlayLayer) Render() {
 	l.tileRenderer.Draw(l.frame, 512, 384, 0, false, false)
 	l.tileRenderer.Unbind()
 
+	l.renderHistory()
+
 	l.text.Bind()
 	l.maxPopCache.SetText(fmt.Sprintf("Maximum Population: %d", l.game.Sim.GetMaxPopulation()))
 	if l.maxPopCache.Texture != nil {
 		y = y - float32(l.maxPopCache.Texture.Height)
 		l.text.Draw(l.maxPopCache.Texture, x, y)
 	}
-	// Put a little padding between max pop and cheevos.
+	// Put a little padding between max pop and the score, with the
+	// cheevos earned listed beside it.
 	y -= 15
+	var cheevoY = y
 	for i, item := range l.game.Cheevos.Passed {
 		if textCache, ok = l.cheevosCache[i]; !ok {
 			textCache = twodee.NewTextCache(l.regFont)
@@ -116,16 +143,89 @@ func (l *OverlayLayer) Render() {
 		}
 		textCache.SetText(item)
 		texture = textCache.Texture
+		if texture != nil {
+			cheevoY = cheevoY - float32(texture.Height)
+			l.text.Draw(texture, x+overlayCheevoColumn, cheevoY)
+		}
+	}
+	for i, line := range l.game.Score.Breakdown() {
+		if textCache, ok = l.scoreCache[i]; !ok {
+			textCache = twodee.NewTextCache(l.regFont)
+			l.scoreCache[i] = textCache
+		}
+		textCache.SetText(fmt.Sprintf("%v: %d", line.Label, line.Points))
+		texture = textCache.Texture
 		if texture != nil {
 			y = y - float32(texture.Height)
 			l.text.Draw(texture, x, y)
 		}
 	}
+	if cheevoY < y {
+		y = cheevoY
+	}
+	y -= 15
+	if l.submitted {
+		for i, item := range l.app.HighScores.Lines(overlayHighScores) {
+			if textCache, ok = l.scoresCache[i]; !ok {
+				textCache = twodee.NewTextCache(l.regFont)
+				l.scoresCache[i] = textCache
+			}
+			textCache.SetText(item)
+			texture = textCache.Texture
+			if texture != nil {
+				y = y - float32(texture.Height)
+				l.text.Draw(texture, x, y)
+			}
+		}
+		l.text.Unbind()
+		return
+	}
+	l.nameCache.SetText(fmt.Sprintf("ENTER YOUR NAME: %v_", l.nameInput.Value))
+	if l.nameCache.Texture != nil {
+		y = y - float32(l.nameCache.Texture.Height)
+		l.text.Draw(l.nameCache.Texture, x, y)
+	}
 	l.text.Unbind()
 }
 
+// Charts each planet's population over the game along the bottom of the
+// screen, marking where planets died.
+func (l *OverlayLayer) renderHistory() {
+	var (
+		history = l.game.Sim.History
+		chart   = twodee.Rect(l.offset.X, 15, l.bounds.Max.X-l.offset.X, 105)
+	)
+	l.shapes.Bind()
+	l.shapes.DrawTriangles(history.AreaVertices(chart))
+	l.shapes.DrawLines(history.AnnotationVertices(chart))
+	l.shapes.Unbind()
+}
+
+func (l *OverlayLayer) SubmitScore() {
+	var name = strings.TrimSpace(l.nameInput.Value)
+	if name == "" {
+		name = "ANONYMOUS"
+	}
+	l.app.HighScores.Add(&HighScoreEntry{
+		Name:            name,
+		Score:           l.game.Score.Total(),
+		MaxPopulation:   l.game.Sim.GetMaxPopulation(),
+		PlanetsLaunched: l.game.Sim.PlanetsLaunched,
+		PlanetsLost:     l.game.Sim.PlanetsLost,
+		Cheevos:         len(l.game.Cheevos.Passed),
+		Rewinds:         l.game.Score.Rewinds,
+		Ranked:          l.app.Ranked,
+		Seed:            l.app.Seed,
+		Date:            time.Now(),
+	})
+	if err := l.app.HighScores.Save(); err != nil {
+		log.Printf("Could not save high scores: %v", err)
+	}
+	l.submitted = true
+}
+
 func (l *OverlayLayer) HandleEvent(evt twodee.Event) bool {
-	if !l.visible {
+	if !l.app.State.Is(StateGameOver) {
 		return true
 	}
 	switch event := evt.(type) {
@@ -133,9 +233,13 @@ func (l *OverlayLayer) HandleEvent(evt twodee.Event) bool {
 		if event.Type != twodee.Press {
 			break
 		}
-		switch event.Code {
-		case twodee.KeyEnter:
+		switch {
+		case l.app.Keys.Is(ActionConfirm, event.Code) && !l.submitted:
+			l.SubmitScore()
+		case l.app.Keys.Is(ActionConfirm, event.Code):
 			return l.NewGame()
+		case !l.submitted:
+			l.nameInput.HandleKey(event.Code)
 		}
 	}
 	// Handle all events.
@@ -143,13 +247,13 @@ func (l *OverlayLayer) HandleEvent(evt twodee.Event) bool {
 }
 
 func (l *OverlayLayer) NewGame() bool {
-	// Can't handle new games yet...
+	if err := l.game.NewGame(time.Now().UnixNano()); err != nil {
+		log.Printf("Could not start game: %v", err)
+		return false
+	}
+	l.nameInput = NewTextInput(maxNameLength)
+	l.submitted = false
 	return false
-	//	if err := l.Reset(); err != nil {
-	//		// TODO: Ugh, see if we can reset GameLayer?
-	//		return false
-	//	}
-	//	return false
 }
 
 func (l *OverlayLayer) Update(elapsed time.Duration) {
@@ -160,9 +264,11 @@ func (l *OverlayLayer) Reset() (err error) {
 	if l.tileRenderer, err = twodee.NewTileRenderer(l.bounds, l.app.WinBounds, l.tileM); err != nil {
 		return
 	}
-	l.gameOverObserver = l.events.AddObserver(GameOver, l.OnGameOver)
 	if l.text, err = twodee.NewTextRenderer(l.bounds); err != nil {
 		return
 	}
+	if l.shapes, err = NewShapeRenderer(l.bounds); err != nil {
+		return
+	}
 	return
 }
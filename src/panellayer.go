@@ -0,0 +1,152 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+// PanelLayer shows a full screen page of text, such as the player profile,
+// until it is dismissed.
+type PanelLayer struct {
+	app        *Application
+	events     *EventBus
+	text       *twodee.TextRenderer
+	titleFont  *twodee.FontFace
+	regFont    *twodee.FontFace
+	titleCache *twodee.TextCache
+	lineCache  map[int]*twodee.TextCache
+	lines      []string
+	bounds     twodee.Rectangle
+	offset     twodee.Point
+	visible    bool
+}
+
+func NewPanelLayer(app *Application, offset twodee.Point) (layer *PanelLayer, err error) {
+	var (
+		titleFont *twodee.FontFace
+		regFont   *twodee.FontFace
+		bg        = color.Transparent
+		font      = "assets/fonts/Exo-SemiBold.ttf"
+	)
+	if titleFont, err = twodee.NewFontFace(font, 32, hiColor, bg); err != nil {
+		return
+	}
+	if regFont, err = twodee.NewFontFace(font, 24, regColor, bg); err != nil {
+		return
+	}
+	layer = &PanelLayer{
+		app:        app,
+		events:     app.Events,
+		titleFont:  titleFont,
+		regFont:    regFont,
+		titleCache: twodee.NewTextCache(titleFont),
+		lineCache:  map[int]*twodee.TextCache{},
+		lines:      []string{},
+		bounds:     app.WinBounds,
+		offset:     offset,
+		visible:    false,
+	}
+	if err = layer.Reset(); err != nil {
+		return
+	}
+	layer.events.OnPanel(layer, layer.OnShowPanel)
+	return
+}
+
+func (l *PanelLayer) OnShowPanel(event *PanelEvent) {
+	l.titleCache.SetText(event.Title)
+	l.lines = event.Lines
+	l.visible = true
+}
+
+func (l *PanelLayer) Hide() {
+	l.visible = false
+	l.events.Enqueue(twodee.NewBasicGameEvent(HidePanel))
+}
+
+func (l *PanelLayer) Delete() {
+	l.visible = false
+	if l.text != nil {
+		l.text.Delete()
+	}
+	l.titleCache.Delete()
+	for _, v := range l.lineCache {
+		v.Delete()
+	}
+	l.events.Release(l)
+}
+
+func (l *PanelLayer) Render() {
+	if !l.visible {
+		return
+	}
+	var (
+		y         = l.bounds.Max.Y - l.offset.Y
+		x         = l.offset.X
+		textCache *twodee.TextCache
+		ok        bool
+	)
+	l.text.Bind()
+	if l.titleCache.Texture != nil {
+		y = y - float32(l.titleCache.Texture.Height)
+		l.text.Draw(l.titleCache.Texture, x, y)
+	}
+	// Put a little padding between the title and the body.
+	y -= 15
+	for i, line := range l.lines {
+		if textCache, ok = l.lineCache[i]; !ok {
+			textCache = twodee.NewTextCache(l.regFont)
+			l.lineCache[i] = textCache
+		}
+		textCache.SetText(line)
+		if textCache.Texture != nil {
+			y = y - float32(textCache.Texture.Height)
+			l.text.Draw(textCache.Texture, x, y)
+		} else {
+			// Blank lines still take up space.
+			y -= 24
+		}
+	}
+	l.text.Unbind()
+}
+
+func (l *PanelLayer) HandleEvent(evt twodee.Event) bool {
+	if !l.visible {
+		return true
+	}
+	switch event := evt.(type) {
+	case *twodee.KeyEvent:
+		if event.Type != twodee.Press {
+			break
+		}
+		if l.app.Keys.Is(ActionMenu, event.Code) || l.app.Keys.Is(ActionConfirm, event.Code) {
+			l.Hide()
+		}
+	case *twodee.MouseButtonEvent:
+		if event.Type == twodee.Press {
+			l.Hide()
+		}
+	}
+	// The panel covers everything beneath it.
+	return false
+}
+
+func (l *PanelLayer) Update(elapsed time.Duration) {
+}
+
+// Recreates the text renderer. The observer is added once, by the
+// constructor.
+func (l *PanelLayer) Reset() (err error) {
+	if l.text != nil {
+		l.text.Delete()
+	}
+	if l.text, err = twodee.NewTextRenderer(l.bounds); err != nil {
+		return
+	}
+	return
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+// BodyView is a copy of the state of a sun or planet.
+type BodyView struct {
+	Id          int
+	Name        string
+	Pos         twodee.Point
+	Velocity    twodee.Point
+	Mass        float32
+	Radius      float32
+	Population  int
+	Temperature int32
+	State       PlanetaryState
+	Age         time.Duration
+}
+
+func newBodyView(p *PlanetaryBody) BodyView {
+	return BodyView{
+		Id:          p.Id,
+		Name:        p.Name,
+		Pos:         p.Pos(),
+		Velocity:    p.Velocity,
+		Mass:        p.Mass,
+		Radius:      p.Radius,
+		Population:  p.GetPopulation(),
+		Temperature: p.GetTemperature(),
+		State:       p.State,
+		Age:         p.Age,
+	}
+}
+
+// SimView lets a player look at a simulation without being able to change
+// it.
+type SimView struct {
+	sim *Simulation
+}
+
+func NewSimView(sim *Simulation) SimView {
+	return SimView{sim}
+}
+
+func (v SimView) Tick() int {
+	return v.sim.Tick
+}
+
+// Returns the playing field, without the margin planets may stray into
+// before they are lost.
+func (v SimView) Bounds() twodee.Rectangle {
+	return v.sim.Field
+}
+
+func (v SimView) Population() int {
+	return v.sim.GetPopulation()
+}
+
+func (v SimView) Sun() BodyView {
+	return newBodyView(v.sim.Sun)
+}
+
+// Returns every living planet.
+func (v SimView) Planets() (planets []BodyView) {
+	for _, p := range v.sim.Planets {
+		if p.IsAlive() {
+			planets = append(planets, newBodyView(p))
+		}
+	}
+	return
+}
+
+// Returns the orbit of a planet around the sun, and false if there is no
+// living planet with that id.
+func (v SimView) Orbit(id int) (o Orbit, ok bool) {
+	var p = v.sim.Body(id)
+	if p == nil || !p.IsAlive() {
+		return
+	}
+	return PredictOrbit(p, v.sim.Sun), true
+}
+
+func (v SimView) CircularDragEnd(pt twodee.Point) twodee.Point {
+	return v.sim.CircularDragEnd(pt)
+}
+
+// PlayerAction is half of a drag: pressing to drop a planet, or letting go to
+// throw it.
+type PlayerAction struct {
+	Release bool
+	Pos     twodee.Point
+}
+
+func Drop(pt twodee.Point) PlayerAction {
+	return PlayerAction{false, pt}
+}
+
+func Release(pt twodee.Point) PlayerAction {
+	return PlayerAction{true, pt}
+}
+
+// Player is anything that launches planets by looking at the simulation,
+// such as a bot.
+type Player interface {
+	// Called every tick with the time since the last. Returns what to do,
+	// in order.
+	Play(elapsed time.Duration, view SimView) []PlayerAction
+}
+
+// Sends a bot's actions as the same events the mouse sends, so that they are
+// traced and logged like the player's. They are marked as the bot's, and only
+// a launcher listening for the bot acts on them, so that a bot's drag never
+// picks up the planet a human is dragging, or the other way round.
+func ApplyActions(events *EventBus, actions []PlayerAction) {
+	for _, a := range actions {
+		if a.Release {
+			var e = NewReleasePlanetEvent(a.Pos.X, a.Pos.Y)
+			e.Bot = true
+			events.Enqueue(e)
+		} else {
+			var e = NewDropPlanetEvent(a.Pos.X, a.Pos.Y)
+			e.Bot = true
+			events.Enqueue(e)
+		}
+	}
+}
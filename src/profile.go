@@ -0,0 +1,164 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	dataDirName     = "sol"
+	profileFileName = "profile.json"
+)
+
+// Returns the path of a file in the per-user data directory, creating the
+// directory if needed.
+func userDataPath(name string) (path string, err error) {
+	var dir string
+	switch runtime.GOOS {
+	case "windows":
+		dir = filepath.Join(os.Getenv("APPDATA"), dataDirName)
+	case "darwin":
+		dir = filepath.Join(os.Getenv("HOME"), "Library", "Application Support", dataDirName)
+	default:
+		dir = filepath.Join(os.Getenv("HOME"), "."+dataDirName)
+	}
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	path = filepath.Join(dir, name)
+	return
+}
+
+type ProfileCheevo struct {
+	Label       string
+	FirstEarned time.Time
+	LastEarned  time.Time
+	Times       int
+}
+
+type Profile struct {
+	Cheevos                []*ProfileCheevo
+	BestMaxPopulation      int
+	GamesPlayed            int
+	PlanetsLostToFire      int
+	PlanetsLostToCollision int
+	PlanetsLostToVoid      int
+	path                   string
+}
+
+// Loads the profile from the user data directory. The profile returned is
+// usable even if there is an error, starting empty if it could not be read.
+func LoadProfile() (profile *Profile, err error) {
+	var (
+		data   []byte
+		loaded Profile
+	)
+	profile = &Profile{
+		Cheevos: []*ProfileCheevo{},
+	}
+	if profile.path, err = userDataPath(profileFileName); err != nil {
+		return
+	}
+	if data, err = ioutil.ReadFile(profile.path); err != nil {
+		if os.IsNotExist(err) {
+			err = nil
+		}
+		return
+	}
+	// Parse into a copy so that a bad file leaves the profile empty.
+	loaded = *profile
+	if err = json.Unmarshal(data, &loaded); err != nil {
+		err = fmt.Errorf("Could not parse profile %v: %v", profile.path, err)
+		return
+	}
+	*profile = loaded
+	return
+}
+
+func (p *Profile) Save() (err error) {
+	var data []byte
+	if data, err = json.MarshalIndent(p, "", "  "); err != nil {
+		return
+	}
+	return ioutil.WriteFile(p.path, data, 0644)
+}
+
+func (p *Profile) RecordCheevo(label string, when time.Time) {
+	for _, c := range p.Cheevos {
+		if c.Label == label {
+			c.LastEarned = when
+			c.Times++
+			return
+		}
+	}
+	p.Cheevos = append(p.Cheevos, &ProfileCheevo{
+		Label:       label,
+		FirstEarned: when,
+		LastEarned:  when,
+		Times:       1,
+	})
+}
+
+// Records a finished game and writes the profile to disk. Nothing is
+// recorded while a game is in progress, as a rewind could take it back.
+func (p *Profile) RecordGame(sim *Simulation, cheevos []string) error {
+	var now = time.Now()
+	p.GamesPlayed++
+	if sim.GetMaxPopulation() > p.BestMaxPopulation {
+		p.BestMaxPopulation = sim.GetMaxPopulation()
+	}
+	for _, death := range sim.History.Deaths {
+		switch death.Cause {
+		case Exploding:
+			p.PlanetsLostToFire++
+		case Colliding:
+			p.PlanetsLostToCollision++
+		case Escaped:
+			p.PlanetsLostToVoid++
+		}
+	}
+	for _, label := range cheevos {
+		p.RecordCheevo(label, now)
+	}
+	return p.Save()
+}
+
+func (p *Profile) HasCheevo(label string) bool {
+	for _, c := range p.Cheevos {
+		if c.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+// A player is experienced once they have made it through the tutorial.
+func (p *Profile) IsExperienced() bool {
+	return p.HasCheevo(MakeFirstPlanetLabel)
+}
+
+func (p *Profile) Summary() []string {
+	var lines = []string{
+		fmt.Sprintf("GAMES PLAYED: %d", p.GamesPlayed),
+		fmt.Sprintf("BEST POPULATION: %d", p.BestMaxPopulation),
+		fmt.Sprintf("PLANETS LOST TO FIRE: %d", p.PlanetsLostToFire),
+		fmt.Sprintf("PLANETS LOST TO COLLISIONS: %d", p.PlanetsLostToCollision),
+		fmt.Sprintf("PLANETS LOST TO THE VOID: %d", p.PlanetsLostToVoid),
+		"",
+	}
+	if len(p.Cheevos) == 0 {
+		return append(lines, "NO CHEEVOS EARNED YET")
+	}
+	for _, c := range p.Cheevos {
+		lines = append(lines, fmt.Sprintf("%v  x%d  %v", c.Label, c.Times, c.FirstEarned.Format("2006-01-02")))
+	}
+	return lines
+}
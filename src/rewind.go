@@ -0,0 +1,142 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// How often the game state is captured.
+	RewindInterval = 30 * twodee.Step60Hz
+	// How far back the player may rewind.
+	RewindWindow = 30 * time.Second
+	// How long each snapshot is shown while scrubbing backwards.
+	RewindScrubRate = 4 * twodee.Step60Hz
+)
+
+// Bodies are restored in place so that anything holding a *PlanetaryBody,
+// such as an active cheevo, still points at the same planet afterwards.
+type bodySnapshot struct {
+	body   *PlanetaryBody
+	value  PlanetaryBody
+	entity twodee.AnimatingEntity
+}
+
+func newBodySnapshot(p *PlanetaryBody) bodySnapshot {
+	return bodySnapshot{
+		body:   p,
+		value:  *p,
+		entity: *p.AnimatingEntity,
+	}
+}
+
+func (b bodySnapshot) restore() {
+	*b.body = b.value
+	*b.body.AnimatingEntity = b.entity
+}
+
+type SimulationSnapshot struct {
+	sun                 bodySnapshot
+	planets             []bodySnapshot
+	aggregatePopulation int
+	maxPopulation       int
+	planetsLaunched     int
+	planetsLost         int
+	history             historySnapshot
+	tick                int
+}
+
+func (s *Simulation) Snapshot() *SimulationSnapshot {
+	var snap = &SimulationSnapshot{
+		sun:                 newBodySnapshot(s.Sun),
+		planets:             make([]bodySnapshot, len(s.Planets)),
+		aggregatePopulation: s.AggregatePopulation,
+		maxPopulation:       s.MaxPopulation,
+		planetsLaunched:     s.PlanetsLaunched,
+		planetsLost:         s.PlanetsLost,
+		history:             s.History.snapshot(),
+		tick:                s.Tick,
+	}
+	for i, p := range s.Planets {
+		snap.planets[i] = newBodySnapshot(p)
+	}
+	return snap
+}
+
+func (s *Simulation) Restore(snap *SimulationSnapshot) {
+	snap.sun.restore()
+	s.Planets = make([]*PlanetaryBody, len(snap.planets))
+	for i, b := range snap.planets {
+		b.restore()
+		s.Planets[i] = b.body
+	}
+	s.AggregatePopulation = snap.aggregatePopulation
+	s.MaxPopulation = snap.maxPopulation
+	s.PlanetsLaunched = snap.planetsLaunched
+	s.PlanetsLost = snap.planetsLost
+	s.History.restore(snap.history)
+	s.Tick = snap.tick
+}
+
+type GameSnapshot struct {
+	sim     *SimulationSnapshot
+	cheevos *CheevosSnapshot
+	score   *ScoreSnapshot
+}
+
+// RewindBuffer is a ring buffer holding the most recent game snapshots.
+type RewindBuffer struct {
+	snapshots []*GameSnapshot
+	start     int
+	count     int
+	elapsed   time.Duration
+}
+
+func NewRewindBuffer() *RewindBuffer {
+	return &RewindBuffer{
+		snapshots: make([]*GameSnapshot, int(RewindWindow/RewindInterval)),
+		start:     0,
+		count:     0,
+		elapsed:   0,
+	}
+}
+
+// Captures a snapshot every RewindInterval.
+func (r *RewindBuffer) Update(elapsed time.Duration, capture func() *GameSnapshot) {
+	r.elapsed += elapsed
+	if r.elapsed >= RewindInterval {
+		r.elapsed -= RewindInterval
+		r.Push(capture())
+	}
+}
+
+func (r *RewindBuffer) Push(snap *GameSnapshot) {
+	var size = len(r.snapshots)
+	r.snapshots[(r.start+r.count)%size] = snap
+	if r.count < size {
+		r.count++
+	} else {
+		r.start = (r.start + 1) % size
+	}
+}
+
+// Removes and returns the most recent snapshot, or nil if none are left.
+func (r *RewindBuffer) Pop() (snap *GameSnapshot) {
+	if r.count == 0 {
+		return nil
+	}
+	r.count--
+	var index = (r.start + r.count) % len(r.snapshots)
+	snap = r.snapshots[index]
+	r.snapshots[index] = nil
+	r.elapsed = 0
+	return
+}
+
+func (r *RewindBuffer) Len() int {
+	return r.count
+}
@@ -0,0 +1,156 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// Person-seconds of population needed for one point.
+	PersonSecondsPerPoint = 1000.0
+	CheevoBonus           = 1000
+	// A planet counts as being in a stable orbit once it has survived this
+	// long, and earns points for every second it then spends in the life zone.
+	StableOrbitAge       = 10 * time.Second
+	StableOrbitPerSecond = 10.0
+	// Each soul lost with a planet costs this many points.
+	LostPopulationPenalty = 0.1
+	RewindPenalty         = 500
+)
+
+type ScoreLine struct {
+	Label  string
+	Points int
+}
+
+// Score integrates the state of the simulation over a whole game, so that a
+// long lived civilisation beats a short population spike.
+type Score struct {
+	sim            *Simulation
+	events         *EventBus
+	PersonSeconds  float64
+	StableSeconds  float64
+	Cheevos        int
+	LostPopulation int
+	Rewinds        int
+	// Planets already counted as lost, as their death events repeat until
+	// they are removed.
+	lost map[int]bool
+}
+
+func NewScore(events *EventBus, sim *Simulation) (score *Score) {
+	score = &Score{
+		sim:    sim,
+		events: events,
+		lost:   map[int]bool{},
+	}
+	events.Subscribe(score, CheevoSuccess, score.OnCheevoSuccess)
+	events.OnPlanet(score, PlanetFireDeath, score.OnPlanetLost)
+	events.OnPlanet(score, PlanetCollision, score.OnPlanetLost)
+	events.OnPlanet(score, PlanetEscaped, score.OnPlanetLost)
+	return
+}
+
+func (s *Score) Delete() {
+	s.events.Release(s)
+}
+
+func (s *Score) Update(elapsed time.Duration) {
+	s.PersonSeconds += float64(s.sim.GetPopulation()) * elapsed.Seconds()
+	for _, p := range s.sim.Planets {
+		if p.State == Fertile && p.Age > StableOrbitAge {
+			s.StableSeconds += elapsed.Seconds()
+		}
+	}
+}
+
+func (s *Score) OnCheevoSuccess(e twodee.GETyper) {
+	s.Cheevos++
+}
+
+func (s *Score) OnPlanetLost(event *PlanetEvent) {
+	if s.lost[event.PlanetId] {
+		return
+	}
+	s.lost[event.PlanetId] = true
+	s.LostPopulation += event.Population
+}
+
+// Returns each component of the score, finishing with the total.
+func (s *Score) Breakdown() []ScoreLine {
+	var (
+		lines = []ScoreLine{
+			ScoreLine{
+				fmt.Sprintf("POPULATION OVER TIME (%d PERSON-SECONDS)", int64(s.PersonSeconds)),
+				int(s.PersonSeconds / PersonSecondsPerPoint),
+			},
+			ScoreLine{
+				fmt.Sprintf("STABLE ORBITS (%d PLANET-SECONDS)", int64(s.StableSeconds)),
+				int(s.StableSeconds * StableOrbitPerSecond),
+			},
+			ScoreLine{
+				fmt.Sprintf("CHEEVOS (%d)", s.Cheevos),
+				s.Cheevos * CheevoBonus,
+			},
+			ScoreLine{
+				fmt.Sprintf("LOST PLANETS (%d SOULS)", s.LostPopulation),
+				-int(float64(s.LostPopulation) * LostPopulationPenalty),
+			},
+			ScoreLine{
+				fmt.Sprintf("REWINDS (%d)", s.Rewinds),
+				-s.Rewinds * RewindPenalty,
+			},
+		}
+		total = 0
+	)
+	for _, line := range lines {
+		total += line.Points
+	}
+	return append(lines, ScoreLine{"TOTAL SCORE", total})
+}
+
+func (s *Score) Total() int {
+	var lines = s.Breakdown()
+	return lines[len(lines)-1].Points
+}
+
+// ScoreSnapshot holds everything a rewind rolls back. The rewind count itself
+// is deliberately left alone.
+type ScoreSnapshot struct {
+	personSeconds  float64
+	stableSeconds  float64
+	cheevos        int
+	lostPopulation int
+	lost           map[int]bool
+}
+
+func (s *Score) Snapshot() *ScoreSnapshot {
+	return &ScoreSnapshot{
+		personSeconds:  s.PersonSeconds,
+		stableSeconds:  s.StableSeconds,
+		cheevos:        s.Cheevos,
+		lostPopulation: s.LostPopulation,
+		lost:           copyIdSet(s.lost),
+	}
+}
+
+func (s *Score) Restore(snap *ScoreSnapshot) {
+	s.PersonSeconds = snap.personSeconds
+	s.StableSeconds = snap.stableSeconds
+	s.Cheevos = snap.cheevos
+	s.LostPopulation = snap.lostPopulation
+	s.lost = copyIdSet(snap.lost)
+}
+
+func copyIdSet(ids map[int]bool) map[int]bool {
+	var c = make(map[int]bool, len(ids))
+	for id := range ids {
+		c[id] = true
+	}
+	return c
+}
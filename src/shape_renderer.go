@@ -0,0 +1,138 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	twodee "../libs/twodee"
+	"github.com/go-gl/gl"
+)
+
+const SHAPE_FRAGMENT = `#version 150
+precision mediump float;
+
+in vec4 v_Color;
+out vec4 v_FragData;
+
+void main()
+{
+  v_FragData = v_Color;
+}`
+
+const SHAPE_VERTEX = `#version 150
+
+in vec2 a_Position;
+in vec4 a_Color;
+uniform mat4 m_ProjectionMatrix;
+
+out vec4 v_Color;
+
+void main()
+{
+    v_Color = a_Color;
+    gl_Position = m_ProjectionMatrix * vec4(a_Position, 0.0, 1.0);
+}`
+
+// ShapeRenderer draws flat coloured lines and triangles in world coordinates.
+type ShapeRenderer struct {
+	shader        gl.Program
+	positionLoc   gl.AttribLocation
+	colorLoc      gl.AttribLocation
+	projectionLoc gl.UniformLocation
+	buffer        gl.Buffer
+	projection    [16]float32
+	data          []float32
+}
+
+func NewShapeRenderer(bounds twodee.Rectangle) (r *ShapeRenderer, err error) {
+	r = &ShapeRenderer{
+		data: []float32{},
+	}
+	if r.shader, err = twodee.BuildProgram(SHAPE_VERTEX, SHAPE_FRAGMENT); err != nil {
+		return
+	}
+	r.positionLoc = r.shader.GetAttribLocation("a_Position")
+	r.colorLoc = r.shader.GetAttribLocation("a_Color")
+	r.projectionLoc = r.shader.GetUniformLocation("m_ProjectionMatrix")
+	r.shader.BindFragDataLocation(0, "v_FragData")
+	r.buffer = gl.GenBuffer()
+	r.SetWorldBounds(bounds)
+	return
+}
+
+// Builds an orthographic projection for the given world rectangle.
+func (r *ShapeRenderer) SetWorldBounds(bounds twodee.Rectangle) {
+	var (
+		w = bounds.Max.X - bounds.Min.X
+		h = bounds.Max.Y - bounds.Min.Y
+	)
+	r.projection = [16]float32{
+		2 / w, 0, 0, 0,
+		0, 2 / h, 0, 0,
+		0, 0, -1, 0,
+		-(bounds.Max.X + bounds.Min.X) / w, -(bounds.Max.Y + bounds.Min.Y) / h, 0, 1,
+	}
+}
+
+func (r *ShapeRenderer) Bind() error {
+	r.shader.Use()
+	r.projectionLoc.UniformMatrix4fv(false, r.projection)
+	r.buffer.Bind(gl.ARRAY_BUFFER)
+	r.positionLoc.EnableArray()
+	r.colorLoc.EnableArray()
+	return nil
+}
+
+func (r *ShapeRenderer) Unbind() error {
+	r.positionLoc.DisableArray()
+	r.colorLoc.DisableArray()
+	r.buffer.Unbind(gl.ARRAY_BUFFER)
+	return nil
+}
+
+func (r *ShapeRenderer) draw(mode gl.GLenum, vertices []ShapeVertex) {
+	if len(vertices) == 0 {
+		return
+	}
+	r.data = r.data[:0]
+	for _, v := range vertices {
+		r.data = append(r.data,
+			v.Pos.X,
+			v.Pos.Y,
+			float32(v.Color.R)/255.0,
+			float32(v.Color.G)/255.0,
+			float32(v.Color.B)/255.0,
+			float32(v.Color.A)/255.0,
+		)
+	}
+	gl.BufferData(gl.ARRAY_BUFFER, len(r.data)*4, r.data, gl.STREAM_DRAW)
+	r.positionLoc.AttribPointer(2, gl.FLOAT, false, 6*4, uintptr(0))
+	r.colorLoc.AttribPointer(4, gl.FLOAT, false, 6*4, uintptr(2*4))
+	gl.DrawArrays(mode, 0, len(vertices))
+}
+
+func (r *ShapeRenderer) DrawLineStrip(vertices []ShapeVertex) {
+	r.draw(gl.LINE_STRIP, vertices)
+}
+
+func (r *ShapeRenderer) DrawLineLoop(vertices []ShapeVertex) {
+	r.draw(gl.LINE_LOOP, vertices)
+}
+
+func (r *ShapeRenderer) DrawLines(vertices []ShapeVertex) {
+	r.draw(gl.LINES, vertices)
+}
+
+func (r *ShapeRenderer) DrawTriangles(vertices []ShapeVertex) {
+	r.draw(gl.TRIANGLES, vertices)
+}
+
+func (r *ShapeRenderer) DrawTriangleStrip(vertices []ShapeVertex) {
+	r.draw(gl.TRIANGLE_STRIP, vertices)
+}
+
+func (r *ShapeRenderer) Delete() error {
+	r.buffer.Delete()
+	r.shader.Delete()
+	return nil
+}
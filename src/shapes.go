@@ -0,0 +1,60 @@
+package main
+
+import (
+	"image/color"
+	"math"
+
+	twodee "../libs/twodee"
+)
+
+// ShapeVertex is a point of a shape drawn by the ShapeRenderer. Building
+// vertices needs no GL, so the simulation code can do it too.
+type ShapeVertex struct {
+	Pos   twodee.Point
+	Color color.RGBA
+}
+
+// Convenience function returning vertices evenly spaced around a circle.
+func CircleVertices(center twodee.Point, radius float32, segments int, c color.RGBA) []ShapeVertex {
+	var vertices = make([]ShapeVertex, segments)
+	for i := 0; i < segments; i++ {
+		var a = 2 * math.Pi * float64(i) / float64(segments)
+		vertices[i] = ShapeVertex{
+			twodee.Pt(
+				center.X+radius*float32(math.Cos(a)),
+				center.Y+radius*float32(math.Sin(a)),
+			),
+			c,
+		}
+	}
+	return vertices
+}
+
+// Returns a triangle strip filling the ring between two radii, blending from
+// the inner colour to the outer one.
+func AnnulusVertices(center twodee.Point, inner, outer float32, segments int, ci, co color.RGBA) []ShapeVertex {
+	var vertices = make([]ShapeVertex, 0, 2*(segments+1))
+	for i := 0; i <= segments; i++ {
+		var (
+			a   = 2 * math.Pi * float64(i) / float64(segments)
+			cos = float32(math.Cos(a))
+			sin = float32(math.Sin(a))
+		)
+		vertices = append(vertices,
+			ShapeVertex{twodee.Pt(center.X+inner*cos, center.Y+inner*sin), ci},
+			ShapeVertex{twodee.Pt(center.X+outer*cos, center.Y+outer*sin), co},
+		)
+	}
+	return vertices
+}
+
+// Returns two triangles filling a rectangle.
+func RectangleVertices(r twodee.Rectangle, c color.RGBA) []ShapeVertex {
+	var (
+		a = ShapeVertex{twodee.Pt(r.Min.X, r.Min.Y), c}
+		b = ShapeVertex{twodee.Pt(r.Max.X, r.Min.Y), c}
+		d = ShapeVertex{twodee.Pt(r.Max.X, r.Max.Y), c}
+		e = ShapeVertex{twodee.Pt(r.Min.X, r.Max.Y), c}
+	)
+	return []ShapeVertex{a, b, d, a, d, e}
+}
@@ -2,7 +2,9 @@ package main
 
 import (
 	twodee "../libs/twodee"
+	"fmt"
 	"math"
+	"math/rand"
 	"time"
 )
 
@@ -12,30 +14,76 @@ const (
 	// Play GC in m^3kg^-1ms^-2
 	GravConst    = 5e-8
 	BoundsBuffer = 10.0
+	// Planets closer to the sun than this burn, further away they freeze.
+	TooCloseDist = 12.0
+	TooFarDist   = 30.0
+	// Starting time is 5minutes.
+	startDur = time.Duration(5) * time.Minute
 )
 
+// The world as seen at the default zoom, in world units.
+var defaultViewBounds = twodee.Rect(-48, -36, 48, 36)
+
+// Returns the playing field for a field scale, relative to the default view.
+func FieldBounds(scale float32) twodee.Rectangle {
+	return twodee.Rect(
+		defaultViewBounds.Min.X*scale,
+		defaultViewBounds.Min.Y*scale,
+		defaultViewBounds.Max.X*scale,
+		defaultViewBounds.Max.Y*scale,
+	)
+}
+
+// Checks a field scale given on the command line. The field cannot be
+// smaller than the default view, so smaller scales are raised to 1.
+func CheckFieldScale(scale float64) (float32, error) {
+	if scale <= 0 {
+		return 0, fmt.Errorf("Field scale must be greater than 0, not %v", scale)
+	}
+	if scale < 1 {
+		return 1, nil
+	}
+	return float32(scale), nil
+}
+
 type Simulation struct {
 	Sun                 *PlanetaryBody
 	Planets             []*PlanetaryBody
 	AggregatePopulation int
 	MaxPopulation       int
-	Events              *twodee.GameEventHandler
-	Bounds              twodee.Rectangle
+	PlanetsLaunched     int
+	PlanetsLost         int
+	History             *PopulationHistory
+	Events              *EventBus
+	Tick                int              // Updates since the game began.
+	Field               twodee.Rectangle // Where planets can be launched.
+	Bounds              twodee.Rectangle // Planets beyond these are lost.
+	// Each simulation has its own source of randomness so that a seed
+	// always plays out the same, even with other simulations running.
+	Rand  *rand.Rand
+	names *NamePicker
 }
 
-func NewSimulation(bounds twodee.Rectangle, events *twodee.GameEventHandler) *Simulation {
+func NewSimulation(bounds twodee.Rectangle, events *EventBus, seed int64) *Simulation {
+	var r = rand.New(rand.NewSource(seed))
 	return &Simulation{
 		Sun:                 NewSun(),
 		Planets:             []*PlanetaryBody{},
 		AggregatePopulation: 0,
 		MaxPopulation:       0,
+		PlanetsLaunched:     0,
+		PlanetsLost:         0,
+		History:             NewPopulationHistory(),
 		Events:              events,
+		Field:               bounds,
 		Bounds: twodee.Rect(
 			bounds.Min.X-BoundsBuffer,
 			bounds.Min.Y-BoundsBuffer,
 			bounds.Max.X+BoundsBuffer,
 			bounds.Max.Y+BoundsBuffer,
 		),
+		Rand:  r,
+		names: NewNamePicker(r),
 	}
 }
 
@@ -44,6 +92,7 @@ func (s *Simulation) Update(elapsed time.Duration) {
 		dist   float32
 		popSum = 0
 	)
+	s.Tick++
 	s.nBodyUpdate(elapsed)
 	s.Sun.Update(elapsed)
 	for _, p := range s.Planets {
@@ -55,9 +104,9 @@ func (s *Simulation) Update(elapsed time.Duration) {
 		dist = p.Pos().DistanceTo(s.Sun.Pos())
 		p.SetDistToSun(float64(dist))
 		switch {
-		case dist < 12:
+		case dist < TooCloseDist:
 			p.SetState(TooClose)
-		case dist > 30:
+		case dist > TooFarDist:
 			p.SetState(TooFar)
 		default:
 			p.SetState(Fertile)
@@ -65,6 +114,7 @@ func (s *Simulation) Update(elapsed time.Duration) {
 	}
 	s.setPopulation(popSum)
 	s.doCollisions()
+	s.History.Update(elapsed, s.Planets)
 	s.doRemoveDeadPlanets()
 }
 
@@ -72,18 +122,23 @@ func (s *Simulation) doCollisions() {
 	for index := 0; index < len(s.Planets); index++ {
 		for j := index + 1; j < len(s.Planets); j++ {
 			if s.Planets[index].CollidesWith(s.Planets[j]) {
-				s.Events.Enqueue(NewPlanetEvent(PlanetCollision, s.Planets[index]))
-				s.Events.Enqueue(NewPlanetEvent(PlanetCollision, s.Planets[j]))
+				s.Events.Enqueue(NewPlanetEvent(PlanetCollision, s.Planets[index], s.Tick))
+				s.Events.Enqueue(NewPlanetEvent(PlanetCollision, s.Planets[j], s.Tick))
 				s.destroyPlanet(index, Colliding)
 				s.destroyPlanet(j, Colliding)
 			}
 		}
 		if s.Planets[index].CollidesWith(s.Sun) {
-			s.Events.Enqueue(NewPlanetEvent(PlanetFireDeath, s.Planets[index]))
+			s.Events.Enqueue(NewPlanetEvent(PlanetFireDeath, s.Planets[index], s.Tick))
 			s.destroyPlanet(index, Exploding)
 		}
 		if !s.Bounds.ContainsPoint(s.Planets[index].Pos()) {
-			s.Planets[index].SetState(Dead)
+			if s.Planets[index].IsAlive() {
+				s.Events.Enqueue(NewPlanetEvent(PlanetEscaped, s.Planets[index], s.Tick))
+				s.History.RecordDeath(s.Planets[index], Escaped)
+				s.PlanetsLost++
+			}
+			s.Planets[index].SetState(Dead | Escaped)
 		}
 	}
 }
@@ -97,36 +152,129 @@ func (s *Simulation) doRemoveDeadPlanets() {
 }
 
 func (s *Simulation) nBodyUpdate(elapsed time.Duration) {
-	var dist float64
 	for _, p := range s.Planets {
 		if p.HasState(Dying) || p.HasState(Dead) {
 			continue
 		}
-		// First, we must handle the sun...
-		dist = float64(s.Sun.Pos().DistanceTo(p.Pos()))
-		var force = s.Sun.Pos().Sub(p.Pos()).Scale(s.Sun.Mass * p.Mass).Scale(float32(math.Pow(dist, -3)))
-		for _, p2 := range s.Planets {
-			if p == p2 {
-				continue
-			}
-			dist = float64(p2.Pos().DistanceTo(p.Pos()))
-			force = force.Add(p2.Pos().Sub(p.Pos()).Scale(p2.Mass * p.Mass).Scale(float32(math.Pow(dist, -3))))
+		p.CalcNewVelocity(s.AccelerationAt(p.Pos(), p), elapsed)
+	}
+}
+
+// Returns the pull of a body on a point, without the gravitational constant.
+func gravityFrom(body *PlanetaryBody, pt twodee.Point) twodee.Point {
+	var dist = float64(body.Pos().DistanceTo(pt))
+	return body.Pos().Sub(pt).Scale(body.Mass).Scale(float32(math.Pow(dist, -3)))
+}
+
+// Returns the gravitational acceleration at pt due to the sun and every
+// planet except skip.
+func (s *Simulation) AccelerationAt(pt twodee.Point, skip *PlanetaryBody) twodee.Point {
+	// First, we must handle the sun...
+	var accel = gravityFrom(s.Sun, pt)
+	for _, p := range s.Planets {
+		if p == skip {
+			continue
 		}
-		// Don't forget the gravitational constant and p's mass.
-		var accel = force.Scale(GravConst).Scale(1 / p.Mass)
-		p.CalcNewVelocity(accel, elapsed)
+		accel = accel.Add(gravityFrom(p, pt))
+	}
+	return accel.Scale(GravConst)
+}
+
+// Returns the depth of the gravity well at pt, as a positive number.
+func (s *Simulation) PotentialAt(pt twodee.Point) float64 {
+	var potential = float64(s.Sun.Mass) / float64(s.Sun.Pos().DistanceTo(pt))
+	for _, p := range s.Planets {
+		potential += float64(p.Mass) / float64(p.Pos().DistanceTo(pt))
 	}
+	return potential * GravConst
+}
+
+// Returns how long until p crosses the edge of the simulation, assuming it
+// carries on in a straight line, or false if it is not going to escape.
+func (s *Simulation) TimeToEscape(p *PlanetaryBody) (d time.Duration, ok bool) {
+	var (
+		pos = p.Pos()
+		v   = p.Velocity
+		t   = math.Inf(1)
+	)
+	// A bound orbit that stays inside the edge will swing back.
+	if o := PredictOrbit(p, s.Sun); o.Bound && s.Bounds.ContainsPoint(o.ApoapsisPos(s.Sun)) {
+		return
+	}
+	if v.X > 0 {
+		t = math.Min(t, float64((s.Bounds.Max.X-pos.X)/v.X))
+	} else if v.X < 0 {
+		t = math.Min(t, float64((s.Bounds.Min.X-pos.X)/v.X))
+	}
+	if v.Y > 0 {
+		t = math.Min(t, float64((s.Bounds.Max.Y-pos.Y)/v.Y))
+	} else if v.Y < 0 {
+		t = math.Min(t, float64((s.Bounds.Min.Y-pos.Y)/v.Y))
+	}
+	if math.IsInf(t, 1) {
+		return
+	}
+	// Velocity is in units/ms.
+	return time.Duration(t * float64(time.Millisecond)), true
+}
+
+// Returns the living planet under pt, or nil.
+func (s *Simulation) PlanetAt(pt twodee.Point) *PlanetaryBody {
+	for _, p := range s.Planets {
+		if p.IsAlive() && p.Pos().DistanceTo(pt) <= p.Radius {
+			return p
+		}
+	}
+	return nil
+}
+
+// Returns the centre of mass of the sun and every living planet.
+func (s *Simulation) Barycentre() twodee.Point {
+	var (
+		pos  = s.Sun.Pos()
+		mass = s.Sun.Mass
+		sum  = pos.Scale(mass)
+	)
+	for _, p := range s.Planets {
+		if !p.IsAlive() {
+			continue
+		}
+		sum = sum.Add(p.Pos().Scale(p.Mass))
+		mass += p.Mass
+	}
+	return sum.Scale(1 / mass)
+}
+
+// Returns the sun or planet with the given id, or nil if it is not in the
+// simulation.
+func (s *Simulation) Body(id int) *PlanetaryBody {
+	if s.Sun.Id == id {
+		return s.Sun
+	}
+	for _, p := range s.Planets {
+		if p.Id == id {
+			return p
+		}
+	}
+	return nil
 }
 
 func (s *Simulation) AddPlanet(p *PlanetaryBody) {
 	s.Planets = append(s.Planets, p)
+	s.PlanetsLaunched++
 }
 
 func (s *Simulation) removePlanet(index int) {
 	s.Planets = append(s.Planets[:index], s.Planets[index+1:]...)
 }
 
+// Planets stay where they are while dying, and may be destroyed again on
+// later ticks; only the first time counts as a loss.
 func (s *Simulation) destroyPlanet(index int, state PlanetaryState) {
+	if s.Planets[index].IsAlive() {
+		s.History.RecordDeath(s.Planets[index], state)
+		s.PlanetsLost++
+	}
 	s.Planets[index].Destroy(state)
 }
 
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+)
+
+type GameState int
+
+const (
+	// The title is up, perhaps with a demo playing behind it.
+	StateTitle GameState = iota
+	StatePlaying
+	// Paused by the player, who can still step, rewind and look around.
+	StatePaused
+	StateMenu
+	StateGameOver
+	StateClosing
+)
+
+var gameStateNames = []string{
+	"Title",
+	"Playing",
+	"Paused",
+	"Menu",
+	"GameOver",
+	"Closing",
+}
+
+func (s GameState) String() string {
+	return gameStateNames[s]
+}
+
+// The states each state may move to. The game can be closed from anywhere,
+// and the menu can only otherwise go Back to whatever it was opened over.
+// Anything else would let the menu resume a game that is over.
+var gameStateTransitions = map[GameState][]GameState{
+	StateTitle:    {StatePlaying, StateClosing},
+	StatePlaying:  {StatePaused, StateMenu, StateGameOver, StateClosing},
+	StatePaused:   {StatePlaying, StateMenu, StateGameOver, StateClosing},
+	StateMenu:     {StateClosing},
+	StateGameOver: {StatePlaying, StateMenu, StateClosing},
+	StateClosing:  {},
+}
+
+// GameStateMachine tracks where the game is in its flow. Every change of
+// state enqueues a StateExit event for the old state and a StateEnter event
+// for the new one.
+type GameStateMachine struct {
+	events  *EventBus
+	current GameState
+	// The state the menu was opened over.
+	base GameState
+}
+
+func NewGameStateMachine(events *EventBus, initial GameState) *GameStateMachine {
+	return &GameStateMachine{
+		events:  events,
+		current: initial,
+		base:    initial,
+	}
+}
+
+func (m *GameStateMachine) Current() GameState {
+	return m.current
+}
+
+func (m *GameStateMachine) Is(s GameState) bool {
+	return m.current == s
+}
+
+// Returns the current state or, while the menu is open, the state it was
+// opened over.
+func (m *GameStateMachine) Base() GameState {
+	return m.base
+}
+
+func (m *GameStateMachine) CanGoto(s GameState) bool {
+	for _, next := range gameStateTransitions[m.current] {
+		if next == s {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *GameStateMachine) Goto(s GameState) (err error) {
+	if !m.CanGoto(s) {
+		return fmt.Errorf("Cannot go from %v to %v", m.current, s)
+	}
+	m.move(s)
+	return
+}
+
+func (m *GameStateMachine) move(s GameState) {
+	var from = m.current
+	m.current = s
+	if s != StateMenu {
+		m.base = s
+	}
+	m.events.Enqueue(NewStateEvent(StateExit, from, s))
+	m.events.Enqueue(NewStateEvent(StateEnter, from, s))
+}
+
+// Closes the menu, going back to the state it was opened over.
+func (m *GameStateMachine) Back() error {
+	if m.current != StateMenu {
+		return fmt.Errorf("Cannot go back from %v", m.current)
+	}
+	m.move(m.base)
+	return nil
+}
@@ -0,0 +1,172 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"testing"
+
+	twodee "../libs/twodee"
+)
+
+// Returns a state machine that has reached state by allowed moves from the
+// title, along with the bus it sends its events to.
+func stateMachineAt(t *testing.T, state GameState) (*GameStateMachine, *EventBus) {
+	var (
+		events = NewEventBus(false)
+		m      = NewGameStateMachine(events, StateTitle)
+		path   = map[GameState][]GameState{
+			StateTitle:    {},
+			StatePlaying:  {StatePlaying},
+			StatePaused:   {StatePlaying, StatePaused},
+			StateMenu:     {StatePlaying, StateMenu},
+			StateGameOver: {StatePlaying, StateGameOver},
+			StateClosing:  {StateClosing},
+		}
+	)
+	for _, s := range path[state] {
+		if err := m.Goto(s); err != nil {
+			t.Fatalf("could not reach %v: %v", state, err)
+		}
+	}
+	events.Poll()
+	return m, events
+}
+
+func TestGameStateGoto(t *testing.T) {
+	var tests = []struct {
+		from GameState
+		to   GameState
+		ok   bool
+	}{
+		{StateTitle, StatePlaying, true},
+		{StateTitle, StateClosing, true},
+		{StateTitle, StatePaused, false},
+		{StateTitle, StateMenu, false},
+		{StateTitle, StateGameOver, false},
+		{StatePlaying, StatePaused, true},
+		{StatePlaying, StateMenu, true},
+		{StatePlaying, StateGameOver, true},
+		{StatePlaying, StateTitle, false},
+		{StatePaused, StatePlaying, true},
+		{StatePaused, StateGameOver, true},
+		{StatePaused, StateTitle, false},
+		{StateMenu, StatePlaying, false},
+		{StateMenu, StatePaused, false},
+		{StateMenu, StateGameOver, false},
+		{StateMenu, StateClosing, true},
+		{StateMenu, StateMenu, false},
+		{StateMenu, StateTitle, false},
+		{StateGameOver, StatePlaying, true},
+		{StateGameOver, StateMenu, true},
+		{StateGameOver, StatePaused, false},
+		{StateGameOver, StateGameOver, false},
+		{StateClosing, StatePlaying, false},
+		{StateClosing, StateTitle, false},
+	}
+	for _, test := range tests {
+		var (
+			m, _ = stateMachineAt(t, test.from)
+			err  = m.Goto(test.to)
+		)
+		if (err == nil) != test.ok {
+			t.Errorf("%v to %v: got error %v, want allowed %v", test.from, test.to, err, test.ok)
+		}
+		var want = test.from
+		if test.ok {
+			want = test.to
+		}
+		if !m.Is(want) {
+			t.Errorf("%v to %v: in %v, want %v", test.from, test.to, m.Current(), want)
+		}
+	}
+}
+
+func TestGameStateBack(t *testing.T) {
+	var tests = []struct {
+		base GameState
+		ok   bool
+	}{
+		{StatePlaying, true},
+		{StatePaused, true},
+		{StateGameOver, true},
+		{StateTitle, false},
+	}
+	for _, test := range tests {
+		var m, _ = stateMachineAt(t, test.base)
+		if err := m.Goto(StateMenu); (err == nil) != test.ok {
+			t.Errorf("menu over %v: got error %v, want allowed %v", test.base, err, test.ok)
+			continue
+		}
+		if !test.ok {
+			if err := m.Back(); err == nil {
+				t.Errorf("back from %v: want error", test.base)
+			}
+			continue
+		}
+		if m.Base() != test.base {
+			t.Errorf("menu over %v: base is %v", test.base, m.Base())
+		}
+		if err := m.Back(); err != nil {
+			t.Errorf("back to %v: %v", test.base, err)
+		}
+		if !m.Is(test.base) {
+			t.Errorf("back to %v: in %v", test.base, m.Current())
+		}
+	}
+}
+
+func TestGameStateMenuAfterGameOver(t *testing.T) {
+	var (
+		m, events = stateMachineAt(t, StateGameOver)
+		game      = &GameLayer{App: &Application{State: m}}
+		entered   = []GameState{}
+	)
+	events.OnState(game, StateEnter, func(e *StateEvent) {
+		entered = append(entered, e.To)
+	})
+	if err := m.Goto(StateMenu); err != nil {
+		t.Fatal(err)
+	}
+	events.Poll()
+	if game.isRunning() {
+		t.Errorf("simulation runs with the menu open over the end screen")
+	}
+	if err := m.Back(); err != nil {
+		t.Fatal(err)
+	}
+	events.Poll()
+	if game.isRunning() {
+		t.Errorf("simulation runs after closing the menu over the end screen")
+	}
+	for _, s := range entered {
+		if s == StatePlaying {
+			t.Errorf("entered %v, want only %v and %v", entered, StateMenu, StateGameOver)
+		}
+	}
+}
+
+func TestGameStatePauseKeyInMenuAfterGameOver(t *testing.T) {
+	var (
+		m, events = stateMachineAt(t, StateGameOver)
+		game      = &GameLayer{App: &Application{State: m, Keys: defaultKeyBindings()}}
+	)
+	if err := m.Goto(StateMenu); err != nil {
+		t.Fatal(err)
+	}
+	events.Poll()
+	// The menu passes on keys that are not menu actions.
+	for i := 0; i < 2; i++ {
+		game.HandleEvent(&twodee.KeyEvent{Type: twodee.Press, Code: twodee.KeyP})
+		events.Poll()
+	}
+	if !m.Is(StateMenu) {
+		t.Errorf("pause key moved the menu to %v", m.Current())
+	}
+	if game.isRunning() {
+		t.Errorf("simulation runs after the pause key in the menu over the end screen")
+	}
+	if err := m.Back(); err != nil || !m.Is(StateGameOver) {
+		t.Errorf("back from the menu: in %v, error %v", m.Current(), err)
+	}
+}
@@ -0,0 +1,72 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	twodee "../libs/twodee"
+)
+
+type entityTextKey struct {
+	id   int
+	slot int
+}
+
+// EntityTextCaches hands out a text cache per entity and purpose, such as a
+// planet's name label. Caches not asked for since the last Sweep belong to
+// entities that have died or stopped being drawn, and are freed so that long
+// games do not leak textures.
+type EntityTextCaches struct {
+	caches map[entityTextKey]*twodee.TextCache
+	used   map[entityTextKey]bool
+}
+
+func NewEntityTextCaches() *EntityTextCaches {
+	return &EntityTextCaches{
+		caches: map[entityTextKey]*twodee.TextCache{},
+		used:   map[entityTextKey]bool{},
+	}
+}
+
+// Returns the cache for an entity's slot, creating it with font if needed.
+func (c *EntityTextCaches) Get(id, slot int, font *twodee.FontFace) *twodee.TextCache {
+	var (
+		key           = entityTextKey{id, slot}
+		textCache, ok = c.caches[key]
+	)
+	if !ok {
+		textCache = twodee.NewTextCache(font)
+		c.caches[key] = textCache
+	}
+	c.used[key] = true
+	return textCache
+}
+
+// Frees every cache that has not been asked for since the last sweep.
+func (c *EntityTextCaches) Sweep() {
+	for key, textCache := range c.caches {
+		if !c.used[key] {
+			textCache.Delete()
+			delete(c.caches, key)
+		}
+	}
+	c.used = map[entityTextKey]bool{}
+}
+
+// Returns the number of GL textures held.
+func (c *EntityTextCaches) Textures() (count int) {
+	for _, textCache := range c.caches {
+		if textCache.Texture != nil {
+			count++
+		}
+	}
+	return
+}
+
+func (c *EntityTextCaches) Delete() {
+	for _, textCache := range c.caches {
+		textCache.Delete()
+	}
+	c.caches = map[entityTextKey]*twodee.TextCache{}
+	c.used = map[entityTextKey]bool{}
+}
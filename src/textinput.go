@@ -0,0 +1,82 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	twodee "../libs/twodee"
+)
+
+var textInputRunes = map[twodee.KeyCode]rune{
+	twodee.KeyA:     'A',
+	twodee.KeyB:     'B',
+	twodee.KeyC:     'C',
+	twodee.KeyD:     'D',
+	twodee.KeyE:     'E',
+	twodee.KeyF:     'F',
+	twodee.KeyG:     'G',
+	twodee.KeyH:     'H',
+	twodee.KeyI:     'I',
+	twodee.KeyJ:     'J',
+	twodee.KeyK:     'K',
+	twodee.KeyL:     'L',
+	twodee.KeyM:     'M',
+	twodee.KeyN:     'N',
+	twodee.KeyO:     'O',
+	twodee.KeyP:     'P',
+	twodee.KeyQ:     'Q',
+	twodee.KeyR:     'R',
+	twodee.KeyS:     'S',
+	twodee.KeyT:     'T',
+	twodee.KeyU:     'U',
+	twodee.KeyV:     'V',
+	twodee.KeyW:     'W',
+	twodee.KeyX:     'X',
+	twodee.KeyY:     'Y',
+	twodee.KeyZ:     'Z',
+	twodee.Key0:     '0',
+	twodee.Key1:     '1',
+	twodee.Key2:     '2',
+	twodee.Key3:     '3',
+	twodee.Key4:     '4',
+	twodee.Key5:     '5',
+	twodee.Key6:     '6',
+	twodee.Key7:     '7',
+	twodee.Key8:     '8',
+	twodee.Key9:     '9',
+	twodee.KeySpace: ' ',
+	twodee.KeyMinus: '-',
+}
+
+// TextInput collects a short upper case string one key press at a time.
+type TextInput struct {
+	Value string
+	Max   int
+}
+
+func NewTextInput(maxLen int) *TextInput {
+	return &TextInput{
+		Value: "",
+		Max:   maxLen,
+	}
+}
+
+// Returns true if the key changed the value.
+func (t *TextInput) HandleKey(code twodee.KeyCode) bool {
+	if code == twodee.KeyBackspace {
+		if len(t.Value) == 0 {
+			return false
+		}
+		t.Value = t.Value[:len(t.Value)-1]
+		return true
+	}
+	if r, ok := textInputRunes[code]; ok && len(t.Value) < t.Max {
+		t.Value += string(r)
+		return true
+	}
+	return false
+}
+
+func (t *TextInput) Clear() {
+	t.Value = ""
+}
@@ -0,0 +1,176 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"image/color"
+	"log"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// How long the title waits for a key before a bot starts playing.
+	titleIdleTime = 10 * time.Second
+	// The prompt blinks on and off over this period.
+	titleBlinkPeriod = 1200 * time.Millisecond
+	titleLogoSize    = 256
+	titleDemoBot     = "greedy"
+)
+
+var (
+	titleBackgroundColor = color.RGBA{0, 0, 0, 200}
+	// Lighter once the demo starts, so that it can be seen.
+	titleDemoBackgroundColor = color.RGBA{0, 0, 0, 110}
+)
+
+// TitleLayer covers the screen with the logo until a key is pressed. If
+// nobody does, a bot plays a demo game behind it.
+type TitleLayer struct {
+	app          *Application
+	game         *GameLayer
+	tileRenderer *twodee.TileRenderer
+	text         *twodee.TextRenderer
+	shapes       *ShapeRenderer
+	titleCache   *twodee.TextCache
+	promptCache  *twodee.TextCache
+	tileM        twodee.TileMetadata
+	bounds       twodee.Rectangle
+	idle         time.Duration
+}
+
+func NewTitleLayer(app *Application, game *GameLayer) (layer *TitleLayer, err error) {
+	var (
+		titleFont  *twodee.FontFace
+		promptFont *twodee.FontFace
+		bg         = color.Transparent
+		exoFont    = "assets/fonts/Exo-SemiBold.ttf"
+	)
+	if titleFont, err = twodee.NewFontFace(exoFont, 72, hiColor, bg); err != nil {
+		return
+	}
+	if promptFont, err = twodee.NewFontFace(exoFont, 24, regColor, bg); err != nil {
+		return
+	}
+	layer = &TitleLayer{
+		app:         app,
+		game:        game,
+		titleCache:  twodee.NewTextCache(titleFont),
+		promptCache: twodee.NewTextCache(promptFont),
+		tileM: twodee.TileMetadata{
+			Path:       "assets/logo.png",
+			PxPerUnit:  1,
+			TileWidth:  titleLogoSize,
+			TileHeight: titleLogoSize,
+			FramesWide: 1,
+			FramesHigh: 1,
+		},
+		bounds: app.WinBounds,
+		idle:   0,
+	}
+	layer.titleCache.SetText("SOL")
+	layer.promptCache.SetText("PRESS ANY KEY")
+	err = layer.Reset()
+	return
+}
+
+func (l *TitleLayer) Delete() {
+	if l.tileRenderer != nil {
+		l.tileRenderer.Delete()
+	}
+	if l.text != nil {
+		l.text.Delete()
+	}
+	if l.shapes != nil {
+		l.shapes.Delete()
+	}
+}
+
+func (l *TitleLayer) Render() {
+	if !l.app.State.Is(StateTitle) {
+		return
+	}
+	var (
+		cx = (l.bounds.Min.X + l.bounds.Max.X) / 2
+		cy = (l.bounds.Min.Y + l.bounds.Max.Y) / 2
+		y  = cy + titleLogoSize/2
+		bg = titleBackgroundColor
+	)
+	if l.game.Demo {
+		bg = titleDemoBackgroundColor
+	}
+	l.shapes.Bind()
+	l.shapes.DrawTriangles(RectangleVertices(l.bounds, bg))
+	l.shapes.Unbind()
+
+	l.tileRenderer.Bind()
+	l.tileRenderer.Draw(0, cx, y+titleLogoSize/2, 0, false, false)
+	l.tileRenderer.Unbind()
+
+	l.text.Bind()
+	if l.titleCache.Texture != nil {
+		y -= float32(l.titleCache.Texture.Height)
+		l.text.Draw(l.titleCache.Texture, cx-float32(l.titleCache.Texture.Width)/2, y)
+	}
+	y -= 40
+	if l.promptCache.Texture != nil && l.idle%titleBlinkPeriod < titleBlinkPeriod*2/3 {
+		y -= float32(l.promptCache.Texture.Height)
+		l.text.Draw(l.promptCache.Texture, cx-float32(l.promptCache.Texture.Width)/2, y)
+	}
+	l.text.Unbind()
+}
+
+func (l *TitleLayer) Update(elapsed time.Duration) {
+	if !l.app.State.Is(StateTitle) {
+		return
+	}
+	l.idle += elapsed
+	if l.idle >= titleIdleTime && !l.game.Demo {
+		var seed = time.Now().UnixNano()
+		if bot, err := NewBot(titleDemoBot, seed); err == nil {
+			l.game.StartDemo(bot, seed)
+		}
+	}
+}
+
+// Hides the title and hands over to a fresh game, on the seed given at
+// startup whether or not a demo has been playing.
+func (l *TitleLayer) Start() {
+	if err := l.game.NewGame(l.app.Seed); err != nil {
+		log.Printf("Could not start game: %v", err)
+	}
+}
+
+func (l *TitleLayer) HandleEvent(evt twodee.Event) bool {
+	if !l.app.State.Is(StateTitle) {
+		return true
+	}
+	switch event := evt.(type) {
+	case *twodee.KeyEvent:
+		if event.Type == twodee.Press {
+			l.Start()
+		}
+	case *twodee.MouseButtonEvent:
+		if event.Type == twodee.Press {
+			l.Start()
+		}
+	}
+	// Nothing underneath sees input while the title is up.
+	return false
+}
+
+func (l *TitleLayer) Reset() (err error) {
+	l.Delete()
+	if l.tileRenderer, err = twodee.NewTileRenderer(l.bounds, l.app.WinBounds, l.tileM); err != nil {
+		return
+	}
+	if l.text, err = twodee.NewTextRenderer(l.bounds); err != nil {
+		return
+	}
+	if l.shapes, err = NewShapeRenderer(l.bounds); err != nil {
+		return
+	}
+	return
+}
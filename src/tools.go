@@ -0,0 +1,171 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	twodee "../libs/twodee"
+)
+
+const (
+	// Largest change in velocity a single thrust may cause, in units/ms.
+	MaxThrust = 0.01
+	// Thrust has no direction with the cursor closer than this to the
+	// planet's centre.
+	minThrustDistance = 0.01
+	// Speed at which a sacrificed planet falls into the sun, in units/ms,
+	// unless gravity already pulls it in faster.
+	SacrificeSpeed = 0.01
+)
+
+// A Tool acts on the selected planet. Using one costs the planet a fraction
+// of its population and starts a cooldown before it may be used again.
+type Tool struct {
+	Name      string
+	Cooldown  time.Duration
+	Cost      float32
+	remaining time.Duration
+	apply     func(game *GameLayer, p *PlanetaryBody)
+}
+
+func (t *Tool) Ready() bool {
+	return t.remaining <= 0
+}
+
+func (t *Tool) Label() string {
+	if t.Ready() {
+		return t.Name
+	}
+	return fmt.Sprintf("%v (%ds)", t.Name, int64(math.Ceil(t.remaining.Seconds())))
+}
+
+type Toolbox struct {
+	Tools       []*Tool
+	sacrificing []int
+}
+
+func NewToolbox() *Toolbox {
+	return &Toolbox{
+		Tools: []*Tool{
+			&Tool{
+				Name:     "THRUST",
+				Cooldown: 3 * time.Second,
+				Cost:     0.02,
+				apply:    applyThrust,
+			},
+			&Tool{
+				Name:     "RENAME",
+				Cooldown: 1 * time.Second,
+				Cost:     0,
+				apply:    applyRename,
+			},
+			&Tool{
+				Name:     "SACRIFICE",
+				Cooldown: 30 * time.Second,
+				Cost:     0,
+				apply:    applySacrifice,
+			},
+		},
+		sacrificing: []int{},
+	}
+}
+
+func (t *Toolbox) Update(elapsed time.Duration, sim *Simulation) {
+	for _, tool := range t.Tools {
+		if tool.remaining > 0 {
+			tool.remaining -= elapsed
+		}
+	}
+	for i := len(t.sacrificing) - 1; i >= 0; i-- {
+		var p = sim.Body(t.sacrificing[i])
+		if p == nil || !p.IsAlive() {
+			t.sacrificing = append(t.sacrificing[:i], t.sacrificing[i+1:]...)
+			continue
+		}
+		steerIntoSun(p, sim.Sun)
+	}
+}
+
+// Uses the tool at index on a planet, returning an error message if it could
+// not be used.
+func (t *Toolbox) Use(index int, game *GameLayer, p *PlanetaryBody) error {
+	if index < 0 || index >= len(t.Tools) {
+		return fmt.Errorf("NO SUCH TOOL")
+	}
+	var tool = t.Tools[index]
+	if !tool.Ready() {
+		return fmt.Errorf("%v IS NOT READY", tool.Name)
+	}
+	if p == nil || !p.IsAlive() {
+		return fmt.Errorf("SELECT A PLANET FIRST")
+	}
+	p.Population -= p.Population * tool.Cost
+	tool.remaining = tool.Cooldown
+	tool.apply(game, p)
+	return nil
+}
+
+func (t *Toolbox) IsSacrificing(p *PlanetaryBody) bool {
+	for _, id := range t.sacrificing {
+		if id == p.Id {
+			return true
+		}
+	}
+	return false
+}
+
+// Pushes the planet toward the mouse cursor, limited to MaxThrust.
+func applyThrust(game *GameLayer, p *PlanetaryBody) {
+	var (
+		v0     = p.Velocity
+		cursor = twodee.Pt(game.MouseX, game.MouseY)
+	)
+	if p.Pos().DistanceTo(cursor) < minThrustDistance {
+		return
+	}
+	p.MoveToward(cursor)
+	var (
+		dv  = p.Velocity.Sub(v0)
+		mag = dv.DistanceTo(twodee.Pt(0, 0))
+	)
+	if mag > MaxThrust {
+		dv = dv.Scale(MaxThrust / mag)
+	}
+	p.Velocity = v0.Add(dv)
+}
+
+func applyRename(game *GameLayer, p *PlanetaryBody) {
+	game.StartRename()
+}
+
+// Points the planet's velocity straight at the sun, keeping any speed it
+// already has toward it but no less than SacrificeSpeed. Inside the sun the
+// planet is left alone until it burns.
+func steerIntoSun(p *PlanetaryBody, sun *PlanetaryBody) {
+	var (
+		rel  = sun.Pos().Sub(p.Pos())
+		dist = rel.DistanceTo(twodee.Pt(0, 0))
+	)
+	if dist < sun.Radius {
+		return
+	}
+	var (
+		dir    = rel.Scale(1 / dist)
+		inward = p.Velocity.X*dir.X + p.Velocity.Y*dir.Y
+	)
+	if inward < SacrificeSpeed {
+		inward = SacrificeSpeed
+	}
+	p.Velocity = dir.Scale(inward)
+}
+
+// Sends the planet straight into the sun.
+func applySacrifice(game *GameLayer, p *PlanetaryBody) {
+	if !game.Tools.IsSacrificing(p) {
+		game.Tools.sacrificing = append(game.Tools.sacrificing, p.Id)
+	}
+}
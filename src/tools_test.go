@@ -0,0 +1,41 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"testing"
+
+	twodee "../libs/twodee"
+)
+
+func TestSacrificeEndsInFire(t *testing.T) {
+	for _, r := range []float32{14, 20, 28} {
+		var (
+			events = NewEventBus(false)
+			sim    = NewSimulation(FieldBounds(1), events, 1)
+			game   = &GameLayer{Tools: NewToolbox()}
+			planet = sim.DropPlanet(twodee.Pt(r, 0))
+			death  twodee.GameEventType
+			died   bool
+		)
+		sim.ReleasePlanet(planet, sim.CircularDragEnd(planet.Pos()))
+		applySacrifice(game, planet)
+		for _, kind := range []twodee.GameEventType{PlanetFireDeath, PlanetEscaped, PlanetCollision} {
+			var kind = kind
+			events.OnPlanet(game, kind, func(e *PlanetEvent) {
+				if !died {
+					death, died = kind, true
+				}
+			})
+		}
+		for tick := 0; tick < 60*60 && !died; tick++ {
+			sim.Update(twodee.Step60Hz)
+			game.Tools.Update(twodee.Step60Hz, sim)
+			events.Poll()
+		}
+		if !died || death != PlanetFireDeath {
+			t.Errorf("planet sacrificed at %v: died %v by %v, want %v", r, died, death, PlanetFireDeath)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+//go:build !batch
+// +build !batch
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"os"
+
+	twodee "../libs/twodee"
+)
+
+// TraceRecord is one line of a trace file. Fields that do not apply to an
+// event are left out.
+type TraceRecord struct {
+	Tick       int     `json:"tick"`
+	Event      string  `json:"event"`
+	Body       int     `json:"body,omitempty"`
+	Name       string  `json:"name,omitempty"`
+	Population int     `json:"population,omitempty"`
+	X          float32 `json:"x,omitempty"`
+	Y          float32 `json:"y,omitempty"`
+	VX         float32 `json:"vx,omitempty"`
+	VY         float32 `json:"vy,omitempty"`
+	Message    string  `json:"message,omitempty"`
+	Cheevo     string  `json:"cheevo,omitempty"`
+	State      string  `json:"state,omitempty"`
+	From       string  `json:"from,omitempty"`
+	Bot        bool    `json:"bot,omitempty"`
+}
+
+// TraceSink writes every game event to a file as newline delimited JSON, for
+// studying how games are played.
+type TraceSink struct {
+	file    *os.File
+	encoder *json.Encoder
+	events  *EventBus
+	err     error
+}
+
+var tracedEvents = []twodee.GameEventType{
+	DropPlanet,
+	ReleasePlanet,
+	PlanetBorn,
+	PlanetFireDeath,
+	PlanetCollision,
+	PlanetEscaped,
+	GameOver,
+	DisplayMessage,
+	CheevoSuccess,
+	CheevoFailure,
+	StateEnter,
+}
+
+func NewTraceSink(path string, events *EventBus) (sink *TraceSink, err error) {
+	var file *os.File
+	if file, err = os.Create(path); err != nil {
+		return
+	}
+	sink = &TraceSink{
+		file:    file,
+		encoder: json.NewEncoder(file),
+		events:  events,
+	}
+	for _, t := range tracedEvents {
+		events.Subscribe(sink, t, sink.OnEvent)
+	}
+	return
+}
+
+func (s *TraceSink) OnEvent(e twodee.GETyper) {
+	var record = TraceRecord{
+		Tick:  s.events.Clock(),
+		Event: eventTypeNames[e.GEType()],
+	}
+	switch event := e.(type) {
+	case *DropPlanetEvent:
+		record.X, record.Y = event.X, event.Y
+		record.Bot = event.Bot
+	case *ReleasePlanetEvent:
+		record.X, record.Y = event.X, event.Y
+		record.Bot = event.Bot
+	case *PlanetEvent:
+		// Recorded as the planet was when the event was enqueued.
+		record.Tick = event.Tick
+		record.Body = event.PlanetId
+		record.Name = event.Planet.Name
+		record.Population = event.Population
+		record.X, record.Y = event.Pos.X, event.Pos.Y
+		record.VX, record.VY = event.Velocity.X, event.Velocity.Y
+	case *DisplayMessageEvent:
+		record.Message = event.Message
+	case *CheevoEvent:
+		record.Cheevo = event.Label
+	case *StateEvent:
+		record.State = event.To.String()
+		record.From = event.From.String()
+	}
+	if s.err != nil {
+		return
+	}
+	if s.err = s.encoder.Encode(record); s.err != nil {
+		log.Printf("Could not write trace: %v", s.err)
+	}
+}
+
+func (s *TraceSink) Delete() {
+	s.events.Release(s)
+	s.file.Close()
+}
+
+// TraceSummary accumulates the records of many trace files.
+type TraceSummary struct {
+	Games          int
+	Finished       int
+	Ticks          int
+	Launches       int
+	LaunchSpeed    float64
+	Deaths         map[string]int
+	PopulationLost map[string]int
+	CheevosEarned  int
+	CheevosFailed  int
+}
+
+func NewTraceSummary() *TraceSummary {
+	return &TraceSummary{
+		Deaths:         map[string]int{},
+		PopulationLost: map[string]int{},
+	}
+}
+
+// Returns whether a record marks the start of a new game, rather than the
+// game carrying on after a pause or the menu.
+func (r TraceRecord) StartsGame() bool {
+	return r.Event == "StateEnter" && r.State == StatePlaying.String() &&
+		(r.From == StateTitle.String() || r.From == StateGameOver.String())
+}
+
+// Adds the records read from r. A trace holds every game played while it was
+// being written, and a game is counted as finished if it reached GameOver.
+func (s *TraceSummary) Read(r io.Reader) (err error) {
+	var (
+		scanner  = bufio.NewScanner(r)
+		playing  = false
+		lastTick = 0
+		finished = false
+		// Bodies already counted as dead this game, as death events
+		// repeat while a planet dies.
+		dead = map[int]bool{}
+	)
+	var endGame = func() {
+		if !playing {
+			return
+		}
+		s.Games++
+		s.Ticks += lastTick
+		if finished {
+			s.Finished++
+		}
+		playing, lastTick, finished = false, 0, false
+		dead = map[int]bool{}
+	}
+	for scanner.Scan() {
+		var record TraceRecord
+		if err = json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return
+		}
+		if record.StartsGame() {
+			endGame()
+			playing = true
+		} else if record.Event != "StateEnter" {
+			// Traces written before states were recorded hold one game.
+			playing = true
+		}
+		lastTick = record.Tick
+		switch record.Event {
+		case "PlanetBorn":
+			s.Launches++
+			s.LaunchSpeed += math.Hypot(float64(record.VX), float64(record.VY))
+		case "PlanetFireDeath", "PlanetCollision", "PlanetEscaped":
+			if dead[record.Body] {
+				break
+			}
+			dead[record.Body] = true
+			s.Deaths[record.Event]++
+			s.PopulationLost[record.Event] += record.Population
+		case "CheevoSuccess":
+			s.CheevosEarned++
+		case "CheevoFailure":
+			s.CheevosFailed++
+		case "GameOver":
+			finished = true
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return
+	}
+	endGame()
+	return
+}
+
+func perGame(total, games int) float64 {
+	if games == 0 {
+		return 0
+	}
+	return float64(total) / float64(games)
+}
+
+// Writes the averages over every game read.
+func (s *TraceSummary) Write(w io.Writer) {
+	fmt.Fprintf(w, "games:                %d (%d finished)\n", s.Games, s.Finished)
+	fmt.Fprintf(w, "ticks per game:       %.1f\n", perGame(s.Ticks, s.Games))
+	fmt.Fprintf(w, "launches per game:    %.2f\n", perGame(s.Launches, s.Games))
+	if s.Launches > 0 {
+		fmt.Fprintf(w, "launch speed:         %.4f\n", s.LaunchSpeed/float64(s.Launches))
+	}
+	for _, cause := range []string{"PlanetFireDeath", "PlanetCollision", "PlanetEscaped"} {
+		fmt.Fprintf(w, "%-21s %.2f per game", cause+":", perGame(s.Deaths[cause], s.Games))
+		if s.Deaths[cause] > 0 {
+			fmt.Fprintf(w, ", %.1f population lost each", perGame(s.PopulationLost[cause], s.Deaths[cause]))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "cheevos per game:     %.2f earned, %.2f failed\n",
+		perGame(s.CheevosEarned, s.Games), perGame(s.CheevosFailed, s.Games))
+}
+
+// Summarizes the trace files at paths onto stdout.
+func summarizeTraces(paths []string) (err error) {
+	var summary = NewTraceSummary()
+	for _, path := range paths {
+		var file *os.File
+		if file, err = os.Open(path); err != nil {
+			return
+		}
+		err = summary.Read(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("Could not read trace %v: %v", path, err)
+		}
+	}
+	summary.Write(os.Stdout)
+	return
+}